@@ -0,0 +1,221 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AssistantParams describe an assistant to create.
+type AssistantParams struct {
+	Model        string `json:"model"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Instructions string `json:"instructions,omitempty"`
+
+	// Tools are e.g. map[string]any{"type": "file_search"} or function definitions
+	// in the same format as Options.Tools.
+	Tools         []any          `json:"tools,omitempty"`
+	ToolResources *ToolResources `json:"tool_resources,omitempty"`
+
+	Temperature *float64          `json:"temperature,omitempty"`
+	TopP        *float64          `json:"top_p,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// ToolResources give built-in tools access to files.
+type ToolResources struct {
+	FileSearch      *FileSearchResources      `json:"file_search,omitempty"`
+	CodeInterpreter *CodeInterpreterResources `json:"code_interpreter,omitempty"`
+}
+
+type FileSearchResources struct {
+	VectorStoreIDs []string `json:"vector_store_ids"`
+}
+
+type CodeInterpreterResources struct {
+	FileIDs []string `json:"file_ids"`
+}
+
+// HostedAssistant is an assistant created via CreateAssistant.
+type HostedAssistant struct {
+	ID        string `json:"id"`
+	CreatedAt int64  `json:"created_at"`
+	AssistantParams
+}
+
+// CreateAssistant creates a hosted assistant.
+func CreateAssistant(ctx context.Context, params AssistantParams, client *http.Client, creds Credentials) (*HostedAssistant, error) {
+	const callID = "CreateAssistant"
+	var resp HostedAssistant
+	err := post(ctx, callID, "https://api.openai.com/v1/assistants", client, creds, &params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RetrieveAssistant returns a hosted assistant.
+func RetrieveAssistant(ctx context.Context, assistantID string, client *http.Client, creds Credentials) (*HostedAssistant, error) {
+	const callID = "RetrieveAssistant"
+	var resp HostedAssistant
+	err := get(ctx, callID, "https://api.openai.com/v1/assistants/"+url.PathEscape(assistantID), client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteAssistant deletes a hosted assistant. Its threads are not deleted.
+func DeleteAssistant(ctx context.Context, assistantID string, client *http.Client, creds Credentials) error {
+	const callID = "DeleteAssistant"
+	return deleteObject(ctx, callID, "https://api.openai.com/v1/assistants/"+url.PathEscape(assistantID), client, creds)
+}
+
+// ThreadParams describe a thread to create; all fields are optional.
+type ThreadParams struct {
+	Messages      []ThreadMessageParams `json:"messages,omitempty"`
+	ToolResources *ToolResources        `json:"tool_resources,omitempty"`
+	Metadata      map[string]string     `json:"metadata,omitempty"`
+}
+
+// Thread is a hosted conversation.
+type Thread struct {
+	ID            string            `json:"id"`
+	CreatedAt     int64             `json:"created_at"`
+	ToolResources *ToolResources    `json:"tool_resources"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+// CreateThread creates a thread, optionally with initial messages.
+func CreateThread(ctx context.Context, params ThreadParams, client *http.Client, creds Credentials) (*Thread, error) {
+	const callID = "CreateThread"
+	var resp Thread
+	err := post(ctx, callID, "https://api.openai.com/v1/threads", client, creds, &params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RetrieveThread returns a thread.
+func RetrieveThread(ctx context.Context, threadID string, client *http.Client, creds Credentials) (*Thread, error) {
+	const callID = "RetrieveThread"
+	var resp Thread
+	err := get(ctx, callID, "https://api.openai.com/v1/threads/"+url.PathEscape(threadID), client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteThread deletes a thread with all its messages.
+func DeleteThread(ctx context.Context, threadID string, client *http.Client, creds Credentials) error {
+	const callID = "DeleteThread"
+	return deleteObject(ctx, callID, "https://api.openai.com/v1/threads/"+url.PathEscape(threadID), client, creds)
+}
+
+// ThreadMessageParams describe a message to add to a thread.
+type ThreadMessageParams struct {
+	Role        Role              `json:"role"` // User or Assistant
+	Content     string            `json:"content"`
+	Attachments []Attachment      `json:"attachments,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// Attachment makes an uploaded file available to the given tools, e.g.
+// []any{map[string]any{"type": "file_search"}}.
+type Attachment struct {
+	FileID string `json:"file_id"`
+	Tools  []any  `json:"tools"`
+}
+
+// ThreadMessage is a message of a thread.
+type ThreadMessage struct {
+	ID          string                 `json:"id"`
+	CreatedAt   int64                  `json:"created_at"`
+	ThreadID    string                 `json:"thread_id"`
+	Role        Role                   `json:"role"`
+	Content     []ThreadMessageContent `json:"content"`
+	Status      string                 `json:"status"` // "in_progress", "incomplete" or "completed"
+	AssistantID string                 `json:"assistant_id"`
+	RunID       string                 `json:"run_id"`
+	Attachments []Attachment           `json:"attachments"`
+	Metadata    map[string]string      `json:"metadata"`
+}
+
+// ThreadMessageContent is a part of a ThreadMessage. Only the field matching Type is set.
+type ThreadMessageContent struct {
+	Type string `json:"type"` // "text", "image_file", "image_url" or "refusal"
+	Text *struct {
+		Value       string `json:"value"`
+		Annotations []any  `json:"annotations"`
+	} `json:"text,omitempty"`
+	ImageFile *struct {
+		FileID string `json:"file_id"`
+	} `json:"image_file,omitempty"`
+	ImageURL *struct {
+		URL string `json:"url"`
+	} `json:"image_url,omitempty"`
+	Refusal string `json:"refusal,omitempty"`
+}
+
+// Text returns the concatenated text parts of the message.
+func (m *ThreadMessage) Text() string {
+	var buf strings.Builder
+	for _, c := range m.Content {
+		if c.Text != nil {
+			buf.WriteString(c.Text.Value)
+		}
+	}
+	return buf.String()
+}
+
+// CreateMessage adds a message to a thread.
+func CreateMessage(ctx context.Context, threadID string, params ThreadMessageParams, client *http.Client, creds Credentials) (*ThreadMessage, error) {
+	const callID = "CreateMessage"
+	var resp ThreadMessage
+	err := post(ctx, callID, "https://api.openai.com/v1/threads/"+url.PathEscape(threadID)+"/messages", client, creds, &params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RetrieveMessage returns a message of a thread.
+func RetrieveMessage(ctx context.Context, threadID, messageID string, client *http.Client, creds Credentials) (*ThreadMessage, error) {
+	const callID = "RetrieveMessage"
+	var resp ThreadMessage
+	err := get(ctx, callID, "https://api.openai.com/v1/threads/"+url.PathEscape(threadID)+"/messages/"+url.PathEscape(messageID), client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListMessages returns a page of messages of a thread, newest first, and whether there are more pages.
+func ListMessages(ctx context.Context, threadID string, opt ListOptions, client *http.Client, creds Credentials) ([]*ThreadMessage, bool, error) {
+	const callID = "ListMessages"
+	var resp listResponse[*ThreadMessage]
+	err := get(ctx, callID, opt.query("https://api.openai.com/v1/threads/"+url.PathEscape(threadID)+"/messages"), client, creds, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Data, resp.HasMore, nil
+}
+
+func deleteObject(ctx context.Context, callID, endpoint string, client *http.Client, creds Credentials) error {
+	var resp deleteResponse
+	err := del(ctx, callID, endpoint, client, creds, &resp)
+	if err != nil {
+		return err
+	}
+	if !resp.Deleted {
+		return &Error{
+			CallID:  callID,
+			Message: "object was not deleted",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestAssistants(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("OpenAI-Beta") != "assistants=v2" {
+			http.Error(w, "missing beta header", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/assistants":
+			if string(body) != `{"model":"gpt-4o","name":"Helper","tools":[{"type":"file_search"}],"tool_resources":{"file_search":{"vector_store_ids":["vs_1"]}}}`+"\n" {
+				http.Error(w, "unexpected body "+string(body), http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"id": "asst_1", "object": "assistant", "created_at": 1, "model": "gpt-4o", "name": "Helper", "tools": [{"type": "file_search"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/assistants/asst_1":
+			w.Write([]byte(`{"id": "asst_1", "object": "assistant.deleted", "deleted": true}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/threads":
+			if string(body) != `{"messages":[{"role":"user","content":"Hi"}]}`+"\n" {
+				http.Error(w, "unexpected body "+string(body), http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"id": "thread_1", "object": "thread", "created_at": 1}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/threads/thread_1/messages":
+			w.Write([]byte(`{"id": "msg_2", "object": "thread.message", "thread_id": "thread_1", "role": "user", "content": [{"type": "text", "text": {"value": "More", "annotations": []}}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/threads/thread_1/messages":
+			if r.URL.RawQuery != "limit=2" {
+				http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"object": "list", "data": [
+				{"id": "msg_3", "role": "assistant", "content": [{"type": "text", "text": {"value": "Hello", "annotations": []}}, {"type": "text", "text": {"value": " there", "annotations": []}}]},
+				{"id": "msg_2", "role": "user", "content": [{"type": "text", "text": {"value": "More", "annotations": []}}]}
+			], "has_more": true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	a, err := CreateAssistant(ctx, AssistantParams{
+		Model:         ModelChatGPT4o,
+		Name:          "Helper",
+		Tools:         []any{map[string]any{"type": "file_search"}},
+		ToolResources: &ToolResources{FileSearch: &FileSearchResources{VectorStoreIDs: []string{"vs_1"}}},
+	}, client, Credentials{})
+	if err != nil || a.ID != "asst_1" || a.Name != "Helper" {
+		t.Fatalf("** CreateAssistant = %+v, %v", a, err)
+	}
+	if err := DeleteAssistant(ctx, a.ID, client, Credentials{}); err != nil {
+		t.Errorf("** DeleteAssistant: %v", err)
+	}
+
+	th, err := CreateThread(ctx, ThreadParams{Messages: []ThreadMessageParams{{Role: User, Content: "Hi"}}}, client, Credentials{})
+	if err != nil || th.ID != "thread_1" {
+		t.Fatalf("** CreateThread = %+v, %v", th, err)
+	}
+
+	m, err := CreateMessage(ctx, th.ID, ThreadMessageParams{Role: User, Content: "More"}, client, Credentials{})
+	if err != nil || m.Text() != "More" {
+		t.Errorf("** CreateMessage = %+v, %v", m, err)
+	}
+
+	msgs, more, err := ListMessages(ctx, th.ID, ListOptions{Limit: 2}, client, Credentials{})
+	if err != nil || !more || len(msgs) != 2 || msgs[0].Text() != "Hello there" || msgs[0].Role != Assistant {
+		t.Errorf("** ListMessages = %v, %v, %v", msgs, more, err)
+	}
+}
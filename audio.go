@@ -0,0 +1,204 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// TranscriptionFormat is the response format of Transcribe and Translate.
+type TranscriptionFormat string
+
+const (
+	TranscriptionJSON        TranscriptionFormat = "json"
+	TranscriptionVerboseJSON TranscriptionFormat = "verbose_json" // adds language, duration, segments and words
+	TranscriptionText        TranscriptionFormat = "text"
+	TranscriptionSRT         TranscriptionFormat = "srt"
+	TranscriptionVTT         TranscriptionFormat = "vtt"
+)
+
+// Timestamp granularities of TranscriptionVerboseJSON.
+const (
+	TimestampSegment = "segment"
+	TimestampWord    = "word"
+)
+
+// TranscriptionOptions control Transcribe and Translate. The zero value uses ModelWhisper1
+// and TranscriptionJSON.
+type TranscriptionOptions struct {
+	Model string
+
+	// Language is the ISO-639-1 code of the input language, e.g. "en". Improves accuracy
+	// and latency. Ignored by Translate, which always produces English.
+	Language string
+
+	// Prompt guides the style of the transcript or continues a previous segment.
+	// Should be in the audio language.
+	Prompt string
+
+	Format TranscriptionFormat
+
+	// Temperature between 0 and 1; 0 lets the model pick the temperature automatically.
+	Temperature float64
+
+	// TimestampGranularities are TimestampSegment and/or TimestampWord. Requires
+	// TranscriptionVerboseJSON; segment timestamps are returned by default.
+	TimestampGranularities []string
+}
+
+// Transcription is the result of Transcribe and Translate.
+type Transcription struct {
+	// Text is the transcript. For TranscriptionText, TranscriptionSRT and TranscriptionVTT,
+	// this is the entire response body.
+	Text string `json:"text"`
+
+	// These are only returned with TranscriptionVerboseJSON.
+	Language string                 `json:"language,omitempty"`
+	Duration float64                `json:"duration,omitempty"` // seconds
+	Segments []TranscriptionSegment `json:"segments,omitempty"`
+	Words    []TranscriptionWord    `json:"words,omitempty"`
+}
+
+// TranscriptionSegment is a segment of a transcript with timestamps in seconds.
+type TranscriptionSegment struct {
+	ID               int     `json:"id"`
+	Seek             int     `json:"seek"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Tokens           []int   `json:"tokens"`
+	Temperature      float64 `json:"temperature"`
+	AvgLogprob       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+	NoSpeechProb     float64 `json:"no_speech_prob"`
+}
+
+// TranscriptionWord is a word of a transcript with timestamps in seconds.
+type TranscriptionWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Transcribe converts speech into text in the same language. The audio is read from r
+// and streamed while uploading; filename must have an extension of a supported format
+// (flac, mp3, mp4, mpeg, mpga, m4a, ogg, wav or webm).
+func Transcribe(ctx context.Context, r io.Reader, filename string, opt TranscriptionOptions, client *http.Client, creds Credentials) (*Transcription, error) {
+	const callID = "Transcribe"
+	return transcribe(ctx, callID, "https://api.openai.com/v1/audio/transcriptions", r, filename, opt, client, creds)
+}
+
+// Translate converts speech in any language into English text. Only ModelWhisper1
+// supports translation. See Transcribe for the details.
+func Translate(ctx context.Context, r io.Reader, filename string, opt TranscriptionOptions, client *http.Client, creds Credentials) (*Transcription, error) {
+	const callID = "Translate"
+	opt.Language = ""
+	opt.TimestampGranularities = nil
+	return transcribe(ctx, callID, "https://api.openai.com/v1/audio/translations", r, filename, opt, client, creds)
+}
+
+func transcribe(ctx context.Context, callID, endpoint string, r io.Reader, filename string, opt TranscriptionOptions, client *http.Client, creds Credentials) (*Transcription, error) {
+	if opt.Model == "" {
+		opt.Model = ModelWhisper1
+	}
+	if opt.Format == "" {
+		opt.Format = TranscriptionJSON
+	}
+
+	fields := []formField{
+		{"model", opt.Model},
+		{"response_format", string(opt.Format)},
+	}
+	if opt.Language != "" {
+		fields = append(fields, formField{"language", opt.Language})
+	}
+	if opt.Prompt != "" {
+		fields = append(fields, formField{"prompt", opt.Prompt})
+	}
+	if opt.Temperature != 0 {
+		fields = append(fields, formField{"temperature", strconv.FormatFloat(opt.Temperature, 'f', -1, 64)})
+	}
+	for _, g := range opt.TimestampGranularities {
+		fields = append(fields, formField{"timestamp_granularities[]", g})
+	}
+	body := multipartBody(fields, []formFile{{"file", filename, r}})
+
+	var resp Transcription
+	switch opt.Format {
+	case TranscriptionJSON, TranscriptionVerboseJSON:
+		err := post(ctx, callID, endpoint, client, creds, body, &resp)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		var buf bytes.Buffer
+		err := post(ctx, callID, endpoint, client, creds, body, &buf)
+		if err != nil {
+			return nil, err
+		}
+		resp.Text = buf.String()
+	}
+	return &resp, nil
+}
+
+// SpeechFormat is the audio format produced by Speech.
+type SpeechFormat string
+
+const (
+	SpeechMP3  SpeechFormat = "mp3"
+	SpeechOpus SpeechFormat = "opus"
+	SpeechAAC  SpeechFormat = "aac"
+	SpeechFLAC SpeechFormat = "flac"
+	SpeechWAV  SpeechFormat = "wav"
+	SpeechPCM  SpeechFormat = "pcm" // raw 24kHz 16-bit signed little-endian samples
+)
+
+// Some of the built-in voices of Speech.
+const (
+	VoiceAlloy   = "alloy"
+	VoiceAsh     = "ash"
+	VoiceCoral   = "coral"
+	VoiceEcho    = "echo"
+	VoiceFable   = "fable"
+	VoiceNova    = "nova"
+	VoiceOnyx    = "onyx"
+	VoiceSage    = "sage"
+	VoiceShimmer = "shimmer"
+)
+
+// SpeechOptions control Speech. The zero value uses ModelTTS1, VoiceAlloy and SpeechMP3.
+type SpeechOptions struct {
+	Model  string       `json:"model"`
+	Voice  string       `json:"voice"`
+	Format SpeechFormat `json:"response_format,omitempty"`
+
+	// Speed from 0.25 to 4.0; 0 means the default of 1.0.
+	Speed float64 `json:"speed,omitempty"`
+
+	// Instructions control the tone of the voice. Not supported by ModelTTS1 and ModelTTS1HD.
+	Instructions string `json:"instructions,omitempty"`
+}
+
+// Speech converts text (up to 4096 characters) into spoken audio, streaming the audio
+// into w as it is being generated, so that playback can start before the call returns.
+func Speech(ctx context.Context, text string, opt SpeechOptions, w io.Writer, client *http.Client, creds Credentials) error {
+	const callID = "Speech"
+	if opt.Model == "" {
+		opt.Model = ModelTTS1
+	}
+	if opt.Voice == "" {
+		opt.Voice = VoiceAlloy
+	}
+	req := &speechRequest{
+		SpeechOptions: opt,
+		Input:         text,
+	}
+	return post(ctx, callID, "https://api.openai.com/v1/audio/speech", client, creds, req, w)
+}
+
+type speechRequest struct {
+	SpeechOptions
+	Input string `json:"input"`
+}
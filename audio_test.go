@@ -0,0 +1,92 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTranscribe(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || (r.URL.Path != "/v1/audio/transcriptions" && r.URL.Path != "/v1/audio/translations") {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseMultipartForm(1024); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f, fh, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(f)
+		if string(data) != "RIFF" || fh.Filename != "hello.wav" || r.FormValue("model") != ModelWhisper1 {
+			http.Error(w, "unexpected upload", http.StatusBadRequest)
+			return
+		}
+		switch r.FormValue("response_format") {
+		case "verbose_json":
+			if r.FormValue("language") != "en" || !reflect.DeepEqual(r.MultipartForm.Value["timestamp_granularities[]"], []string{"segment", "word"}) {
+				http.Error(w, "unexpected fields", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"task": "transcribe", "language": "english", "duration": 1.5, "text": "Hello world.",
+				"segments": [{"id": 0, "start": 0, "end": 1.5, "text": " Hello world.", "tokens": [50364, 2425], "no_speech_prob": 0.01}],
+				"words": [{"word": "Hello", "start": 0, "end": 0.6}, {"word": "world", "start": 0.7, "end": 1.4}]}`))
+		case "srt":
+			if r.URL.Path != "/v1/audio/translations" || r.FormValue("language") != "" {
+				http.Error(w, "unexpected translation", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("1\n00:00:00,000 --> 00:00:01,500\nHello world.\n"))
+		default:
+			http.Error(w, "unexpected format", http.StatusBadRequest)
+		}
+	})
+	ctx := context.Background()
+
+	tr, err := Transcribe(ctx, strings.NewReader("RIFF"), "hello.wav", TranscriptionOptions{
+		Language:               "en",
+		Format:                 TranscriptionVerboseJSON,
+		TimestampGranularities: []string{TimestampSegment, TimestampWord},
+	}, client, Credentials{})
+	if err != nil {
+		t.Fatalf("** Transcribe: %v", err)
+	}
+	if tr.Text != "Hello world." || tr.Duration != 1.5 || len(tr.Segments) != 1 || tr.Segments[0].End != 1.5 || len(tr.Words) != 2 || tr.Words[1].Word != "world" {
+		t.Errorf("** Transcribe = %+v", tr)
+	}
+
+	tr, err = Translate(ctx, strings.NewReader("RIFF"), "hello.wav", TranscriptionOptions{Language: "en", Format: TranscriptionSRT}, client, Credentials{})
+	if err != nil {
+		t.Fatalf("** Translate: %v", err)
+	}
+	if !strings.Contains(tr.Text, "00:00:00,000 --> 00:00:01,500") {
+		t.Errorf("** Translate = %q", tr.Text)
+	}
+}
+
+func TestSpeech(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/v1/audio/speech" || string(body) != `{"model":"tts-1","voice":"alloy","response_format":"opus","input":"Hello"}`+"\n" {
+			http.Error(w, "unexpected request "+string(body), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/ogg")
+		w.Write([]byte("OggS"))
+	})
+
+	var buf bytes.Buffer
+	err := Speech(context.Background(), "Hello", SpeechOptions{Format: SpeechOpus}, &buf, client, Credentials{})
+	if err != nil || buf.String() != "OggS" {
+		t.Errorf("** Speech = %q, %v", buf.String(), err)
+	}
+}
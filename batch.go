@@ -0,0 +1,403 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	BatchEndpointChat       = "/v1/chat/completions"
+	BatchEndpointEmbeddings = "/v1/embeddings"
+)
+
+// BatchWriter writes the JSONL input file of a batch. All requests of a batch
+// must go to the same endpoint. Batches are processed within 24 hours at half price.
+type BatchWriter struct {
+	w        io.Writer
+	endpoint string
+	n        int
+}
+
+// NewBatchWriter returns a writer producing a batch input file into w.
+func NewBatchWriter(w io.Writer) *BatchWriter {
+	return &BatchWriter{w: w}
+}
+
+type batchInputLine struct {
+	CustomID string `json:"custom_id"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Body     any    `json:"body"`
+}
+
+// AddChat adds a Chat request to the batch. customID must be unique within the batch.
+func (bw *BatchWriter) AddChat(customID string, messages []Msg, opt Options) error {
+	return bw.add(customID, BatchEndpointChat, &chatRequest{Msgs: messages, Options: opt})
+}
+
+// AddEmbedding adds an embedding request to the batch. customID must be unique within the batch.
+func (bw *BatchWriter) AddEmbedding(customID, input, model string) error {
+	return bw.add(customID, BatchEndpointEmbeddings, &embeddingsRequest{Model: model, Input: input})
+}
+
+func (bw *BatchWriter) add(customID, endpoint string, body any) error {
+	if bw.endpoint != "" && bw.endpoint != endpoint {
+		return fmt.Errorf("cannot add a %s request to a batch of %s requests", endpoint, bw.endpoint)
+	}
+	_, err := bw.w.Write(saneMarshal(&batchInputLine{customID, http.MethodPost, endpoint, body}))
+	if err != nil {
+		return err
+	}
+	bw.endpoint = endpoint
+	bw.n++
+	return nil
+}
+
+// Endpoint returns the endpoint of the requests added so far, to be passed to CreateBatch.
+func (bw *BatchWriter) Endpoint() string {
+	return bw.endpoint
+}
+
+// Count returns the number of requests added so far.
+func (bw *BatchWriter) Count() int {
+	return bw.n
+}
+
+type BatchStatus string
+
+const (
+	BatchValidating BatchStatus = "validating"
+	BatchFailed     BatchStatus = "failed"
+	BatchInProgress BatchStatus = "in_progress"
+	BatchFinalizing BatchStatus = "finalizing"
+	BatchCompleted  BatchStatus = "completed"
+	BatchExpired    BatchStatus = "expired"
+	BatchCancelling BatchStatus = "cancelling"
+	BatchCancelled  BatchStatus = "cancelled"
+)
+
+// IsFinal returns whether the batch has stopped and its status won't change any more.
+func (s BatchStatus) IsFinal() bool {
+	return s == BatchFailed || s == BatchCompleted || s == BatchExpired || s == BatchCancelled
+}
+
+// Batch is the state of a batch.
+type Batch struct {
+	ID               string            `json:"id"`
+	Endpoint         string            `json:"endpoint"`
+	InputFileID      string            `json:"input_file_id"`
+	OutputFileID     string            `json:"output_file_id"`
+	ErrorFileID      string            `json:"error_file_id"`
+	CompletionWindow string            `json:"completion_window"`
+	Status           BatchStatus       `json:"status"`
+	CreatedAt        int64             `json:"created_at"`
+	InProgressAt     int64             `json:"in_progress_at"`
+	ExpiresAt        int64             `json:"expires_at"`
+	CompletedAt      int64             `json:"completed_at"`
+	FailedAt         int64             `json:"failed_at"`
+	RequestCounts    BatchCounts       `json:"request_counts"`
+	Errors           *BatchErrors      `json:"errors"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+type BatchCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// BatchErrors explains why the batch has failed validation.
+type BatchErrors struct {
+	Data []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Param   string `json:"param"`
+		Line    int    `json:"line"`
+	} `json:"data"`
+}
+
+type batchCreateRequest struct {
+	InputFileID      string            `json:"input_file_id"`
+	Endpoint         string            `json:"endpoint"`
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+// SubmitBatch uploads the batch input file read from r and creates a batch for it.
+// Endpoint is usually BatchWriter.Endpoint(); metadata is optional.
+func SubmitBatch(ctx context.Context, r io.Reader, endpoint string, metadata map[string]string, client *http.Client, creds Credentials) (*Batch, error) {
+	f, err := UploadFile(ctx, r, "batch.jsonl", FilePurposeBatch, client, creds)
+	if err != nil {
+		return nil, err
+	}
+	return CreateBatch(ctx, f.ID, endpoint, metadata, client, creds)
+}
+
+// CreateBatch creates a batch from an input file uploaded with FilePurposeBatch.
+func CreateBatch(ctx context.Context, inputFileID, endpoint string, metadata map[string]string, client *http.Client, creds Credentials) (*Batch, error) {
+	const callID = "CreateBatch"
+	req := &batchCreateRequest{
+		InputFileID:      inputFileID,
+		Endpoint:         endpoint,
+		CompletionWindow: "24h",
+		Metadata:         metadata,
+	}
+	var resp Batch
+	err := post(ctx, callID, "https://api.openai.com/v1/batches", client, creds, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RetrieveBatch returns the current state of a batch.
+func RetrieveBatch(ctx context.Context, batchID string, client *http.Client, creds Credentials) (*Batch, error) {
+	const callID = "RetrieveBatch"
+	var resp Batch
+	err := get(ctx, callID, "https://api.openai.com/v1/batches/"+url.PathEscape(batchID), client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CancelBatch cancels an in-progress batch. Results of already completed requests remain available.
+func CancelBatch(ctx context.Context, batchID string, client *http.Client, creds Credentials) (*Batch, error) {
+	const callID = "CancelBatch"
+	var resp Batch
+	err := post(ctx, callID, "https://api.openai.com/v1/batches/"+url.PathEscape(batchID)+"/cancel", client, creds, struct{}{}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListBatches returns a page of batches of the organization, newest first, and whether there are more pages.
+func ListBatches(ctx context.Context, opt ListOptions, client *http.Client, creds Credentials) ([]*Batch, bool, error) {
+	const callID = "ListBatches"
+	var resp listResponse[*Batch]
+	err := get(ctx, callID, opt.query("https://api.openai.com/v1/batches"), client, creds, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Data, resp.HasMore, nil
+}
+
+// WaitForBatch polls the batch every interval until its status is final, and returns its final state.
+func WaitForBatch(ctx context.Context, batchID string, interval time.Duration, client *http.Client, creds Credentials) (*Batch, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		batch, err := RetrieveBatch(ctx, batchID, client, creds)
+		if err != nil {
+			return nil, err
+		}
+		if batch.Status.IsFinal() {
+			return batch, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// BatchChatResult is the result of a Chat request added via BatchWriter.AddChat.
+// Err is set if the request has failed.
+type BatchChatResult struct {
+	CustomID string
+	Msgs     []Msg
+	Usage    Usage
+	Err      error
+}
+
+// BatchEmbeddingResult is the result of a request added via BatchWriter.AddEmbedding.
+// Err is set if the request has failed.
+type BatchEmbeddingResult struct {
+	CustomID  string
+	Embedding []float64
+	Usage     Usage
+	Err       error
+}
+
+// ReadBatchChatResults parses a batch output or error file of Chat requests, keyed by custom ID.
+func ReadBatchChatResults(r io.Reader) (map[string]*BatchChatResult, error) {
+	results := make(map[string]*BatchChatResult)
+	err := readBatchOutput(r, func(customID string, body []byte, err error) error {
+		result := &BatchChatResult{CustomID: customID, Err: err}
+		results[customID] = result
+		if err != nil {
+			return nil
+		}
+		var resp chatResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return err
+		}
+		result.Usage = resp.Usage
+		for _, choice := range resp.Choices {
+			result.Msgs = append(result.Msgs, choice.Msg)
+		}
+		if len(result.Msgs) == 0 {
+			result.Err = &Error{CallID: customID, Message: "no results"}
+		}
+		return nil
+	})
+	return results, err
+}
+
+// ReadBatchEmbeddingResults parses a batch output or error file of embedding requests, keyed by custom ID.
+func ReadBatchEmbeddingResults(r io.Reader) (map[string]*BatchEmbeddingResult, error) {
+	results := make(map[string]*BatchEmbeddingResult)
+	err := readBatchOutput(r, func(customID string, body []byte, err error) error {
+		result := &BatchEmbeddingResult{CustomID: customID, Err: err}
+		results[customID] = result
+		if err != nil {
+			return nil
+		}
+		var resp embeddingsResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return err
+		}
+		result.Usage = resp.Usage
+		if len(resp.Data) == 0 {
+			result.Err = &Error{CallID: customID, Message: "no results"}
+		} else {
+			result.Embedding = resp.Data[0].Embedding
+		}
+		return nil
+	})
+	return results, err
+}
+
+// DownloadBatchChatResults downloads and parses both output and error files of a finished batch.
+func DownloadBatchChatResults(ctx context.Context, batch *Batch, client *http.Client, creds Credentials) (map[string]*BatchChatResult, error) {
+	results := make(map[string]*BatchChatResult)
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		err := downloadAndParse(ctx, fileID, client, creds, func(r io.Reader) error {
+			m, err := ReadBatchChatResults(r)
+			for k, v := range m {
+				results[k] = v
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// DownloadBatchEmbeddingResults downloads and parses both output and error files of a finished batch.
+func DownloadBatchEmbeddingResults(ctx context.Context, batch *Batch, client *http.Client, creds Credentials) (map[string]*BatchEmbeddingResult, error) {
+	results := make(map[string]*BatchEmbeddingResult)
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		err := downloadAndParse(ctx, fileID, client, creds, func(r io.Reader) error {
+			m, err := ReadBatchEmbeddingResults(r)
+			for k, v := range m {
+				results[k] = v
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// downloadAndParse streams a file into parse without holding it in memory. Does nothing if fileID is empty.
+func downloadAndParse(ctx context.Context, fileID string, client *http.Client, creds Credentials, parse func(r io.Reader) error) error {
+	if fileID == "" {
+		return nil
+	}
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := parse(pr)
+		pr.CloseWithError(err) // unblock the download if parsing fails
+		done <- err
+	}()
+	err := DownloadFile(ctx, fileID, pw, client, creds)
+	pw.CloseWithError(err)
+	if parseErr := <-done; err == nil {
+		err = parseErr
+	}
+	return err
+}
+
+type batchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		RequestID  string          `json:"request_id"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// readBatchOutput calls f for every line of a batch output or error file, with either
+// the response body of a successful request or an error describing the failure.
+func readBatchOutput(r io.Reader, f func(customID string, body []byte, err error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+		var line batchOutputLine
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return fmt.Errorf("batch output line %d: %w", lineNo, err)
+		}
+
+		var err error
+		var body []byte
+		if line.Error != nil {
+			err = &Error{
+				CallID:  line.CustomID,
+				Type:    line.Error.Code,
+				Message: strings.TrimSpace(line.Error.Message),
+			}
+		} else if line.Response == nil {
+			err = &Error{
+				CallID:  line.CustomID,
+				Message: "no response",
+			}
+		} else if line.Response.StatusCode < 200 || line.Response.StatusCode > 299 {
+			e := &Error{
+				CallID:            line.CustomID,
+				StatusCode:        line.Response.StatusCode,
+				RawResponseBody:   line.Response.Body,
+				PrintResponseBody: true,
+			}
+			var errResp errorResponse
+			if json.Unmarshal(line.Response.Body, &errResp) == nil && errResp.Error != nil {
+				e.Type, _ = errResp.Error.Type.(string)
+				if s, ok := errResp.Error.Message.(string); ok && s != "" {
+					e.Message, e.PrintResponseBody = strings.TrimSpace(s), false
+				}
+			}
+			err = e
+		} else {
+			body = line.Response.Body
+		}
+
+		if err := f(line.CustomID, body, err); err != nil {
+			return fmt.Errorf("batch output line %d: %w", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
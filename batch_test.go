@@ -0,0 +1,120 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchWriter(t *testing.T) {
+	var buf bytes.Buffer
+	bw := NewBatchWriter(&buf)
+	if err := bw.AddChat("a", []Msg{UserMsg("hi")}, Options{Model: ModelChatGPT4oMini}); err != nil {
+		t.Fatalf("** AddChat: %v", err)
+	}
+	if err := bw.AddEmbedding("b", "hi", ModelEmbeddingAda002); err == nil {
+		t.Errorf("** AddEmbedding into a chat batch succeeded")
+	}
+	if bw.Count() != 1 || bw.Endpoint() != BatchEndpointChat {
+		t.Errorf("** Count = %d, Endpoint = %q", bw.Count(), bw.Endpoint())
+	}
+	const expected = `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"messages":[{"role":"user","content":"hi"}],"model":"gpt-4o-mini","temperature":0,"top_p":0,"presence_penalty":0,"frequency_penalty":0}}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("** got:\n%s\nwanted:\n%s", buf.String(), expected)
+	}
+}
+
+func TestReadBatchChatResults(t *testing.T) {
+	const output = `{"id": "batch_req_1", "custom_id": "ok", "response": {"status_code": 200, "request_id": "r1", "body": {"choices": [{"index": 0, "message": {"role": "assistant", "content": "hello"}}], "usage": {"prompt_tokens": 8, "completion_tokens": 1, "total_tokens": 9}}}, "error": null}
+{"id": "batch_req_2", "custom_id": "bad", "response": {"status_code": 400, "request_id": "r2", "body": {"error": {"message": "Invalid model", "type": "invalid_request_error"}}}, "error": null}
+{"id": "batch_req_3", "custom_id": "expired", "response": null, "error": {"code": "batch_expired", "message": "This request could not be executed before the completion window expired."}}
+`
+	results, err := ReadBatchChatResults(strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("** ReadBatchChatResults: %v", err)
+	}
+	if r := results["ok"]; r == nil || r.Err != nil || len(r.Msgs) != 1 || r.Msgs[0].Content != "hello" || r.Usage.TotalTokens != 9 {
+		t.Errorf("** ok = %+v", r)
+	}
+	var e *Error
+	if r := results["bad"]; r == nil || !errors.As(r.Err, &e) || e.StatusCode != 400 || e.Message != "Invalid model" {
+		t.Errorf("** bad = %+v", r)
+	}
+	if r := results["expired"]; r == nil || !errors.As(r.Err, &e) || e.Type != "batch_expired" {
+		t.Errorf("** expired = %+v", r)
+	}
+
+	if _, err := ReadBatchChatResults(strings.NewReader("{\n")); err == nil {
+		t.Errorf("** malformed output parsed")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var polls int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+			if err := r.ParseMultipartForm(1024); err != nil || r.FormValue("purpose") != "batch" {
+				http.Error(w, "unexpected upload", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"id": "file-in", "purpose": "batch"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/batches":
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"input_file_id":"file-in","endpoint":"/v1/embeddings","completion_window":"24h"}`+"\n" {
+				http.Error(w, "unexpected body "+string(body), http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"id": "batch_1", "status": "validating", "input_file_id": "file-in"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/batches/batch_1":
+			polls++
+			if polls < 3 {
+				w.Write([]byte(`{"id": "batch_1", "status": "in_progress"}`))
+			} else {
+				w.Write([]byte(`{"id": "batch_1", "status": "completed", "output_file_id": "file-out", "error_file_id": "file-err", "request_counts": {"total": 2, "completed": 1, "failed": 1}}`))
+			}
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/files/file-out/content":
+			w.Write([]byte(`{"custom_id": "x", "response": {"status_code": 200, "body": {"data": [{"embedding": [0.5, -0.5]}], "usage": {"prompt_tokens": 1, "total_tokens": 1}}}}` + "\n"))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/files/file-err/content":
+			w.Write([]byte(`{"custom_id": "y", "response": {"status_code": 500, "body": {"error": {"message": "Server error", "type": "server_error"}}}}` + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	bw := NewBatchWriter(&buf)
+	ensure(bw.AddEmbedding("x", "hello", ModelEmbeddingAda002))
+	ensure(bw.AddEmbedding("y", "world", ModelEmbeddingAda002))
+
+	batch, err := SubmitBatch(ctx, &buf, bw.Endpoint(), nil, client, Credentials{})
+	if err != nil || batch.ID != "batch_1" || batch.Status != BatchValidating {
+		t.Fatalf("** SubmitBatch = %+v, %v", batch, err)
+	}
+
+	batch, err = WaitForBatch(ctx, batch.ID, time.Millisecond, client, Credentials{})
+	if err != nil || batch.Status != BatchCompleted || polls != 3 || batch.RequestCounts.Failed != 1 {
+		t.Fatalf("** WaitForBatch = %+v, %v after %d polls", batch, err, polls)
+	}
+
+	results, err := DownloadBatchEmbeddingResults(ctx, batch, client, Credentials{})
+	if err != nil || len(results) != 2 {
+		t.Fatalf("** DownloadBatchEmbeddingResults = %v, %v", results, err)
+	}
+	if r := results["x"]; r.Err != nil || len(r.Embedding) != 2 || r.Embedding[0] != 0.5 {
+		t.Errorf("** x = %+v", r)
+	}
+	if r := results["y"]; r.Err == nil || !strings.Contains(r.Err.Error(), "Server error") {
+		t.Errorf("** y = %+v", r)
+	}
+
+	if _, err := DownloadBatchEmbeddingResults(ctx, &Batch{OutputFileID: "file-404"}, client, Credentials{}); err == nil {
+		t.Errorf("** download of a missing file succeeded")
+	}
+}
@@ -0,0 +1,194 @@
+package openai
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+//go:embed models.json
+var defaultModelCatalog []byte
+
+// modelCatalog is the format of model catalog files, see LoadModelCatalog.
+type modelCatalog struct {
+	Models  []*modelCatalogEntry `json:"models"`
+	Aliases map[string]string    `json:"aliases"`
+}
+
+type modelCatalogEntry struct {
+	Name                 string  `json:"name"`
+	ContextWindow        int     `json:"context_window"`
+	MaxOutputTokens      int     `json:"max_output_tokens,omitempty"`
+	Encoding             string  `json:"encoding,omitempty"`
+	InputPrice           float64 `json:"input_price,omitempty"`
+	CachedInputPrice     float64 `json:"cached_input_price,omitempty"`
+	OutputPrice          float64 `json:"output_price,omitempty"`
+	AudioInputPrice      float64 `json:"audio_input_price,omitempty"`
+	AudioOutputPrice     float64 `json:"audio_output_price,omitempty"`
+	TrainingPrice        float64 `json:"training_price,omitempty"`
+	FineTunedInputPrice  float64 `json:"fine_tuned_input_price,omitempty"`
+	FineTunedOutputPrice float64 `json:"fine_tuned_output_price,omitempty"`
+	SupportsTools        bool    `json:"supports_tools,omitempty"`
+	SupportsVision       bool    `json:"supports_vision,omitempty"`
+	SupportsJSON         bool    `json:"supports_json,omitempty"`
+}
+
+func init() {
+	ensure(loadModelCatalog(defaultModelCatalog, "embedded models.json"))
+}
+
+// LoadModelCatalog reads a model catalog and registers all models and aliases from it
+// (see RegisterModel and RegisterModelAlias), overriding built-in models with the same names. Nothing is registered if the catalog is invalid.
+//
+// Use this to update prices and context windows via configuration, without waiting for
+// a new version of this library. The catalog is a JSON file like this:
+//
+//	{
+//	  "models": [
+//	    {
+//	      "name": "gpt-4o",
+//	      "context_window": 128000,
+//	      "max_output_tokens": 4096,
+//	      "encoding": "o200k_base",
+//	      "input_price": 5.00,
+//	      "cached_input_price": 2.50,
+//	      "output_price": 15.00,
+//	      "training_price": 25.00,
+//	      "fine_tuned_input_price": 3.75,
+//	      "fine_tuned_output_price": 15.00,
+//	      "supports_tools": true,
+//	      "supports_vision": true,
+//	      "supports_json": true
+//	    }
+//	  ],
+//	  "aliases": {
+//	    "chatgpt-4o-latest": "gpt-4o"
+//	  }
+//	}
+//
+// Prices are in US dollars per 1M tokens, the way OpenAI publishes them. Audio models
+// also have audio_input_price and audio_output_price. Only name and
+// context_window are required. See models.json in this package for the built-in catalog.
+func LoadModelCatalog(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return loadModelCatalog(data, "")
+}
+
+// LoadModelCatalogFile is like LoadModelCatalog, but reads the catalog from the given file.
+func LoadModelCatalogFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return loadModelCatalog(data, path)
+}
+
+func loadModelCatalog(data []byte, source string) error {
+	catalog, err := parseModelCatalog(data)
+	if err != nil {
+		if source != "" {
+			err = fmt.Errorf("%s: %w", source, err)
+		}
+		return err
+	}
+	for _, e := range catalog.Models {
+		RegisterModel(e.modelInfo())
+	}
+	for alias, model := range catalog.Aliases {
+		RegisterModelAlias(alias, model)
+	}
+	return nil
+}
+
+// ParseModelCatalog parses and validates a model catalog in the format described
+// in LoadModelCatalog, without registering the models. Aliases are not returned.
+func ParseModelCatalog(data []byte) ([]ModelInfo, error) {
+	catalog, err := parseModelCatalog(data)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ModelInfo, 0, len(catalog.Models))
+	for _, e := range catalog.Models {
+		result = append(result, e.modelInfo())
+	}
+	return result, nil
+}
+
+func parseModelCatalog(data []byte) (*modelCatalog, error) {
+	var catalog modelCatalog
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields() // catch typos in field names
+	if err := d.Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("invalid model catalog: %w", err)
+	}
+
+	seen := make(map[string]bool, len(catalog.Models))
+	for i, e := range catalog.Models {
+		if e == nil || e.Name == "" {
+			return nil, fmt.Errorf("invalid model catalog: model #%d has no name", i+1)
+		}
+		if seen[e.Name] {
+			return nil, fmt.Errorf("invalid model catalog: duplicate model %q", e.Name)
+		}
+		seen[e.Name] = true
+		if err := e.validate(); err != nil {
+			return nil, fmt.Errorf("invalid model catalog: model %q: %w", e.Name, err)
+		}
+	}
+	for alias, model := range catalog.Aliases {
+		if alias == "" || model == "" {
+			return nil, fmt.Errorf("invalid model catalog: empty alias or alias target")
+		}
+		if seen[alias] {
+			return nil, fmt.Errorf("invalid model catalog: alias %q is also a model", alias)
+		}
+	}
+	return &catalog, nil
+}
+
+func (e *modelCatalogEntry) validate() error {
+	if e.ContextWindow <= 0 {
+		return fmt.Errorf("context_window must be positive")
+	}
+	if e.MaxOutputTokens < 0 || e.MaxOutputTokens > e.ContextWindow {
+		return fmt.Errorf("max_output_tokens must be between 0 and context_window")
+	}
+	switch e.Encoding {
+	case "", EncodingR50k, EncodingP50k, EncodingCL100k, EncodingO200k:
+		break
+	default:
+		return fmt.Errorf("unknown encoding %q", e.Encoding)
+	}
+	for _, p := range []float64{e.InputPrice, e.CachedInputPrice, e.OutputPrice, e.AudioInputPrice, e.AudioOutputPrice, e.TrainingPrice, e.FineTunedInputPrice, e.FineTunedOutputPrice} {
+		if p < 0 {
+			return fmt.Errorf("prices cannot be negative")
+		}
+	}
+	return nil
+}
+
+func (e *modelCatalogEntry) modelInfo() ModelInfo {
+	return ModelInfo{
+		Name:                 e.Name,
+		ContextWindow:        e.ContextWindow,
+		MaxOutputTokens:      e.MaxOutputTokens,
+		Encoding:             e.Encoding,
+		InputPrice:           usdPerM(e.InputPrice),
+		CachedInputPrice:     usdPerM(e.CachedInputPrice),
+		OutputPrice:          usdPerM(e.OutputPrice),
+		AudioInputPrice:      usdPerM(e.AudioInputPrice),
+		AudioOutputPrice:     usdPerM(e.AudioOutputPrice),
+		TrainingPrice:        usdPerM(e.TrainingPrice),
+		FineTunedInputPrice:  usdPerM(e.FineTunedInputPrice),
+		FineTunedOutputPrice: usdPerM(e.FineTunedOutputPrice),
+		SupportsTools:        e.SupportsTools,
+		SupportsVision:       e.SupportsVision,
+		SupportsJSON:         e.SupportsJSON,
+	}
+}
@@ -0,0 +1,47 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseModelCatalog(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`{"models": [{"name": "a", "context_window": 1000, "input_price": 1.5}]}`, ""},
+		{`{"models": [{"context_window": 1000}]}`, "invalid model catalog: model #1 has no name"},
+		{`{"models": [{"name": "a", "context_window": 1000}, {"name": "a", "context_window": 1000}]}`, `invalid model catalog: duplicate model "a"`},
+		{`{"models": [{"name": "a"}]}`, `invalid model catalog: model "a": context_window must be positive`},
+		{`{"models": [{"name": "a", "context_window": 1000, "max_output_tokens": 2000}]}`, `invalid model catalog: model "a": max_output_tokens must be between 0 and context_window`},
+		{`{"models": [{"name": "a", "context_window": 1000, "encoding": "foo"}]}`, `invalid model catalog: model "a": unknown encoding "foo"`},
+		{`{"models": [{"name": "a", "context_window": 1000, "output_price": -1}]}`, `invalid model catalog: model "a": prices cannot be negative`},
+		{`{"models": [{"name": "a", "context_window": 1000, "inptu_price": 1}]}`, `invalid model catalog: json: unknown field "inptu_price"`},
+		{`{"models": [{"name": "a", "context_window": 1000}], "aliases": {"b": "a"}}`, ""},
+		{`{"models": [{"name": "a", "context_window": 1000}], "aliases": {"a": "b"}}`, `invalid model catalog: alias "a" is also a model`},
+	}
+	for _, test := range tests {
+		_, err := ParseModelCatalog([]byte(test.input))
+		var actual string
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != test.err {
+			t.Errorf("** ParseModelCatalog(%s) error = %q, wanted %q", test.input, actual, test.err)
+		}
+	}
+}
+
+func TestLoadModelCatalog(t *testing.T) {
+	const catalog = `{"models": [{"name": "test-catalog-model", "context_window": 5000, "input_price": 1.5, "output_price": 3}]}`
+	if err := LoadModelCatalog(strings.NewReader(catalog)); err != nil {
+		t.Fatalf("** LoadModelCatalog: %v", err)
+	}
+	if a := MaxTokens("test-catalog-model"); a != 5000 {
+		t.Errorf("** MaxTokens = %d, wanted 5000", a)
+	}
+	if a := Cost(1_000_000, 1_000_000, "test-catalog-model").String(); a != "$4.50" {
+		t.Errorf("** Cost = %s, wanted $4.50", a)
+	}
+}
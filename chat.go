@@ -14,13 +14,22 @@ const (
 	System    Role = "system"
 	User      Role = "user"
 	Assistant Role = "assistant"
+	Function  Role = "function"
+	Tool      Role = "tool"
 )
 
 // Msg is a single chat message.
 type Msg struct {
 	Role         Role          `json:"role"`
 	Content      string        `json:"content"`
+	Name         string        `json:"name,omitempty"`
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+
+	// ToolCalls are the tool calls requested by an assistant message.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolCallID is the ID of the call answered by a tool message.
+	ToolCallID string `json:"tool_call_id,omitempty"`
 }
 
 type FunctionCall struct {
@@ -28,6 +37,14 @@ type FunctionCall struct {
 	Arguments string `json:"arguments"`
 }
 
+// ToolCall is a tool call requested by an assistant message, answered by a Tool
+// message with the same ToolCallID.
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"` // "function"
+	Function FunctionCall `json:"function"`
+}
+
 func (msg *Msg) UnmarshalCallArguments(out any) error {
 	return msg.FunctionCall.UnmarshalArguments(out) // tolerates nil
 }
@@ -51,17 +68,17 @@ func (call *FunctionCall) UnmarshalArguments(out any) error {
 
 // SystemMsg makes an Msg with a System role.
 func SystemMsg(content string) Msg {
-	return Msg{System, content, nil}
+	return Msg{Role: System, Content: content}
 }
 
 // UserMsg makes an Msg with a User role.
 func UserMsg(content string) Msg {
-	return Msg{User, content, nil}
+	return Msg{Role: User, Content: content}
 }
 
 // AssistantMsg makes an Msg with an Assistant role.
 func AssistantMsg(content string) Msg {
-	return Msg{Assistant, content, nil}
+	return Msg{Role: Assistant, Content: content}
 }
 
 // DefaultChatOptions provides a safe and conservative starting point for Chat call options.
@@ -88,11 +105,20 @@ func Chat(ctx context.Context, messages []Msg, opt Options, client *http.Client,
 		Options: opt,
 	}
 
+	tc, err := trackCall(ctx, callID, opt.Model, opt.N, func() (int, int) {
+		return ChatPromptTokenCount(messages, opt), opt.MaxTokens
+	})
+	if err != nil {
+		return nil, Usage{}, err
+	}
+
 	var resp chatResponse
-	err := post(ctx, callID, "https://api.openai.com/v1/chat/completions", client, creds, req, &resp)
+	err = post(ctx, callID, "https://api.openai.com/v1/chat/completions", client, creds, req, &resp)
 	if err != nil {
+		tc.finish(nil)
 		return nil, Usage{}, err
 	}
+	tc.finish(&resp.Usage)
 	if len(resp.Choices) == 0 {
 		return nil, Usage{}, &Error{
 			CallID:  callID,
@@ -120,13 +146,31 @@ func StreamChat(ctx context.Context, messages []Msg, opt Options, client *http.C
 		Stream:  true,
 	}
 
+	tc, err := trackCall(ctx, callID, opt.Model, 1, func() (int, int) {
+		return ChatPromptTokenCount(messages, opt), opt.MaxTokens
+	})
+	if err != nil {
+		return Msg{}, err
+	}
+	var usage *Usage
+	if tc != nil {
+		req.StreamOptions = &chatStreamOptions{IncludeUsage: true}
+	}
+	defer func() {
+		tc.finish(usage)
+	}()
+
 	var msg Msg
 	var buf strings.Builder
-	err := post(ctx, callID, "https://api.openai.com/v1/chat/completions", client, creds, req, func(data []byte) error {
+	err = post(ctx, callID, "https://api.openai.com/v1/chat/completions", client, creds, req, func(data []byte) error {
 		var resp chatStreamingResponse
 		if err := json.Unmarshal(data, &resp); err != nil {
 			return err
 		}
+		if resp.Usage != nil && len(resp.Choices) == 0 {
+			usage = resp.Usage // final chunk when StreamOptions.IncludeUsage is set
+			return nil
+		}
 		if len(resp.Choices) != 1 {
 			return fmt.Errorf("expected exactly one choice")
 		}
@@ -146,7 +190,12 @@ func StreamChat(ctx context.Context, messages []Msg, opt Options, client *http.C
 type chatRequest struct {
 	Msgs []Msg `json:"messages"`
 	Options
-	Stream bool `json:"stream,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+	StreamOptions *chatStreamOptions `json:"stream_options,omitempty"`
+}
+
+type chatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type message struct {
@@ -171,6 +220,7 @@ type chatChoice struct {
 
 type chatStreamingResponse struct {
 	Choices []chatStreamingChoice `json:"choices"`
+	Usage   *Usage                `json:"usage"`
 }
 
 type chatStreamingChoice struct {
@@ -25,7 +25,9 @@ func main() {
 		Timeout: 2 * time.Minute, // sometimes this stuff takes a long time to respond
 	}
 
-	var cost openai.Price
+	costs := &openai.CostTracker{}
+	ctx := openai.WithCostTracker(context.Background(), costs, "")
+
 	scanner := bufio.NewScanner(bufio.NewReader(os.Stdin))
 	fmt.Printf("User: ")
 	for scanner.Scan() {
@@ -38,16 +40,15 @@ func main() {
 		chat = append(chat, openai.UserMsg(input))
 		chat, _ = openai.DropChatHistoryIfNeeded(chat, 1, openai.MaxTokens(opt.Model), opt.Model)
 
-		msgs, usage, err := openai.Chat(context.Background(), chat, opt, client, creds)
+		msgs, _, err := openai.Chat(ctx, chat, opt, client, creds)
 		if err != nil {
 			fmt.Printf("** %v\n", err)
 			continue
 		}
-		cost += openai.Cost(usage.PromptTokens, usage.CompletionTokens, opt.Model)
 
 		fmt.Printf("\nChatGPT: %s\n\n", strings.TrimSpace(msgs[0].Content))
 
 		chat = append(chat, msgs[0])
-		fmt.Printf("[%v spent, history has %d tokens in %d messages]\nUser: ", cost, openai.ChatTokenCount(chat, opt.Model), len(chat))
+		fmt.Printf("[%v spent, history has %d tokens in %d messages]\nUser: ", costs.Total().Cost, openai.ChatTokenCount(chat, opt.Model), len(chat))
 	}
 }
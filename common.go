@@ -2,11 +2,24 @@ package openai
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 )
 
 const (
+	// ModelChatGPT41 is the flagship GPT-4.1 model, with a 1M context.
+	ModelChatGPT41 = "gpt-4.1"
+
+	// ModelChatGPT41Mini is a faster and cheaper version of ModelChatGPT41, with a 1M context.
+	ModelChatGPT41Mini = "gpt-4.1-mini"
+
+	// ModelChatGPT41Nano is the fastest and cheapest GPT-4.1 model, with a 1M context.
+	ModelChatGPT41Nano = "gpt-4.1-nano"
+
+	// ModelO1 is the o1 reasoning model, with 200k context.
+	ModelO1 = "o1"
+
+	// ModelO1Mini is a smaller o1 reasoning model, with 128k context.
+	ModelO1Mini = "o1-mini"
+
 	// ModelChatGPT4o is the current best chat model, gpt-4o, with 128k context.
 	ModelChatGPT4o = "gpt-4o"
 
@@ -53,51 +66,50 @@ const (
 	// ModelEmbeddingAda002 is the original embedding model, its use is no longer recommended.
 	ModelEmbeddingAda002 = "text-embedding-ada-002"
 
-	modelChatGPT35TurboPrefix  = "gpt-3.5-turbo-"
-	modelChatGPT4Prefix        = "gpt-4-"
-	modelChatGPT4With32kPrefix = "gpt-4-32k-"
-)
+	// ModelRealtime is the speech-to-speech model of the Realtime API.
+	ModelRealtime = "gpt-4o-realtime-preview"
+
+	// ModelRealtimeMini is a cheaper version of ModelRealtime.
+	ModelRealtimeMini = "gpt-4o-mini-realtime-preview"
+
+	// ModelWhisper1 is the original speech-to-text model, the only one that supports translation.
+	ModelWhisper1 = "whisper-1"
+
+	// ModelTranscribe4o is a GPT-4o based speech-to-text model, more accurate than ModelWhisper1.
+	ModelTranscribe4o = "gpt-4o-transcribe"
+
+	// ModelTranscribe4oMini is a cheaper version of ModelTranscribe4o.
+	ModelTranscribe4oMini = "gpt-4o-mini-transcribe"
+
+	// ModelTTS1 is the text-to-speech model optimized for speed.
+	ModelTTS1 = "tts-1"
+
+	// ModelTTS1HD is the text-to-speech model optimized for quality.
+	ModelTTS1HD = "tts-1-hd"
 
-var (
-	gpt4oSnapshotRe       = regexp.MustCompile(`^gpt-4o-\d{4}-\d{2}-\d{2}$`)
-	gpt4oMiniSnapshotRe   = regexp.MustCompile(`^gpt-4o-mini-\d{4}-\d{2}-\d{2}$`)
-	gpt4TurboSnapshotRe   = regexp.MustCompile(`^gpt-4-turbo-\d{4}-\d{2}-\d{2}$`)
-	gpt35TurboSnapshotRe  = regexp.MustCompile(`^gpt-3.5-turbo-\d{4}$`)
-	gpt4With32kSnapshotRe = regexp.MustCompile(`^gpt-4-32k-\d{4}$`)
-	gpt4SnapshotRe        = regexp.MustCompile(`^gpt-4-\d{4}$`)
+	// ModelTTS4oMini is a GPT-4o based text-to-speech model that follows voice instructions.
+	ModelTTS4oMini = "gpt-4o-mini-tts"
+
+	// ModelDallE2 is the older image model, the only one supporting variations. Cheapest.
+	ModelDallE2 = "dall-e-2"
+
+	// ModelDallE3 is the image generation model with the best prompt following of the DALL·E family.
+	ModelDallE3 = "dall-e-3"
+
+	// ModelGPTImage1 is the GPT-4o based image model, the most capable one for generation and edits.
+	ModelGPTImage1 = "gpt-image-1"
+
+	// ModelModerationOmni is the latest moderation model, supporting both text and images.
+	ModelModerationOmni = "omni-moderation-latest"
+
+	// ModelModerationText is the legacy text-only moderation model.
+	ModelModerationText = "text-moderation-latest"
 )
 
 // MaxTokens returns the maximum number of tokens the given model supports. This is a sum of
-// prompt and completion tokens.
+// prompt and completion tokens. Panics for unknown models; use MaxTokensE to handle them gracefully.
 func MaxTokens(model string) int {
-	switch model {
-	case "ada", "babbage", "curie", ModelBaseDavinci, "text-ada-001", "text-babbage-001", "text-curie-001":
-		return 2048
-	case "code-davinci-002", "text-davinci-002":
-		return 4000 // from docs: https://platform.openai.com/docs/models/gpt-3-5
-	case "text-davinci-003":
-		return 4097
-	case ModelChatGPT35Turbo:
-		return 4096
-	case ModelChatGPT4:
-		return 8192
-	case ModelChatGPT4With32k:
-		return 32768
-	case ModelChatGPT4Turbo, ModelChatGPT4TurboPreview, "gpt-4-1106-preview", "gpt-4-0125-preview":
-		return 128000
-	case ModelChatGPT4o, ModelChatGPT4oMini:
-		return 128000
-	case ModelEmbeddingAda002, ModelEmbedding3Small, ModelEmbedding3Large:
-		return 8192
-	default:
-		if base, _, ok := strings.Cut(model, ":ft-"); ok {
-			return MaxTokens(base)
-		}
-		if generic := snapshotToGeneric(model); generic != "" {
-			return MaxTokens(generic)
-		}
-		panic(fmt.Errorf("unknown model name %q", model))
-	}
+	return must(MaxTokensE(model))
 }
 
 // Price is an amount in 1/1_000_000 of a cent. I.e. $2 per 1M tokens = $0.002 per 1K tokens = Price(200) per token.
@@ -110,98 +122,15 @@ func (p Price) String() string {
 }
 
 // Cost estimates the cost of processing the given number of prompt & completion
-// tokens with the given model.
+// tokens with the given model. Panics for unknown models; use CostE to handle them gracefully.
 func Cost(promptTokens, completionTokens int, model string) Price {
-	switch model {
-	case ModelChatGPT4o:
-		return Price(promptTokens)*500 + Price(completionTokens)*1500
-	case ModelChatGPT4oMini:
-		return Price(promptTokens)*15 + Price(completionTokens)*60
-	case ModelChatGPT4Turbo, ModelChatGPT4TurboPreview, "gpt-4-1106-preview", "gpt-4-0125-preview":
-		return Price(promptTokens)*1000 + Price(completionTokens)*3000
-	case ModelChatGPT4:
-		return Price(promptTokens)*3000 + Price(completionTokens)*6000
-	case ModelChatGPT4With32k:
-		return Price(promptTokens)*6000 + Price(completionTokens)*12000
-	case ModelChatGPT35Turbo:
-		return Price(promptTokens)*50 + Price(completionTokens)*150
-	case "davinci", "text-davinci-003":
-		return Price(promptTokens+completionTokens) * 2000
-	case "curie", "text-curie-001":
-		return Price(promptTokens+completionTokens) * 200
-	case "babbage", "text-babbage-001":
-		return Price(promptTokens+completionTokens) * 50
-	case "ada", "text-ada-001":
-		return Price(promptTokens+completionTokens) * 40
-	case ModelEmbeddingAda002:
-		return Price(promptTokens+completionTokens) * 10
-	case ModelEmbedding3Small:
-		return Price(promptTokens+completionTokens) * 2
-	case ModelEmbedding3Large:
-		return Price(promptTokens+completionTokens) * 13
-	case "code-davinci-002", "text-davinci-002":
-		return Price(promptTokens+completionTokens) * 2000 // just a guess; https://openai.com/pricing doesn't say anything
-	default:
-		if base, _, ok := strings.Cut(model, ":ft-"); ok {
-			switch base {
-			case "davinci":
-				return Price(promptTokens+completionTokens) * 12000
-			case "curie":
-				return Price(promptTokens+completionTokens) * 1200
-			case "babbage":
-				return Price(promptTokens+completionTokens) * 240
-			case "ada":
-				return Price(promptTokens+completionTokens) * 160
-			default:
-				panic(fmt.Errorf("unknown base model name %q in %q", base, model))
-			}
-		}
-		if generic := snapshotToGeneric(model); generic != "" {
-			return Cost(promptTokens, completionTokens, model)
-		}
-		panic(fmt.Errorf("unknown model name %q", model))
-	}
+	return must(CostE(promptTokens, completionTokens, model))
 }
 
 // FineTuningCost estimates the cost of fine-tuning the given model using the given number of tokens of sample data.
+// Panics for unknown models; use FineTuningCostE to handle them gracefully.
 func FineTuningCost(tokens int, model string) Price {
-	switch model {
-	case "davinci":
-		return Price(tokens) * 3000
-	case "curie":
-		return Price(tokens) * 300
-	case "babbage":
-		return Price(tokens) * 60
-	case "ada":
-		return Price(tokens) * 40
-	default:
-		if base, _, ok := strings.Cut(model, ":ft-"); ok {
-			return FineTuningCost(tokens, base)
-		}
-		panic(fmt.Errorf("unknown model name %q", model))
-	}
-}
-
-func snapshotToGeneric(model string) string {
-	if gpt4oSnapshotRe.MatchString(model) {
-		return ModelChatGPT4o
-	}
-	if gpt4oMiniSnapshotRe.MatchString(model) {
-		return ModelChatGPT4oMini
-	}
-	if gpt4TurboSnapshotRe.MatchString(model) {
-		return ModelChatGPT4Turbo
-	}
-	if gpt35TurboSnapshotRe.MatchString(model) {
-		return ModelChatGPT35Turbo
-	}
-	if gpt4With32kSnapshotRe.MatchString(model) {
-		return ModelChatGPT4With32k
-	}
-	if gpt4SnapshotRe.MatchString(model) {
-		return ModelChatGPT4
-	}
-	return ""
+	return must(FineTuningCostE(tokens, model))
 }
 
 // Credentials are used to authenticate with OpenAI.
@@ -257,7 +186,23 @@ const (
 )
 
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens            int                     `json:"prompt_tokens"`
+	CompletionTokens        int                     `json:"completion_tokens"`
+	TotalTokens             int                     `json:"total_tokens"`
+	PromptTokensDetails     PromptTokensDetails     `json:"prompt_tokens_details"`
+	CompletionTokensDetails CompletionTokensDetails `json:"completion_tokens_details"`
+}
+
+// PromptTokensDetails breaks down Usage.PromptTokens; the remaining tokens are uncached text.
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+	AudioTokens  int `json:"audio_tokens"`
+}
+
+// CompletionTokensDetails breaks down Usage.CompletionTokens; the remaining tokens are text.
+type CompletionTokensDetails struct {
+	ReasoningTokens          int `json:"reasoning_tokens"`
+	AudioTokens              int `json:"audio_tokens"`
+	AcceptedPredictionTokens int `json:"accepted_prediction_tokens"`
+	RejectedPredictionTokens int `json:"rejected_prediction_tokens"`
 }
@@ -1,6 +1,9 @@
 package openai
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestCost(t *testing.T) {
 	const fine = "davinci:ft-12345"
@@ -37,25 +40,25 @@ func TestCost(t *testing.T) {
 		// max GPT-3.5 context
 		{3328, 768, ModelChatGPT4, "$0.15"},
 		{3840, 256, ModelChatGPT4, "$0.13"},
-		{3328, 768, ModelChatGPT35Turbo, "$0.01"},
-		{3840, 256, ModelChatGPT35Turbo, "$0.01"},
+		{3328, 768, ModelChatGPT35Turbo, "$0.00"},
+		{3840, 256, ModelChatGPT35Turbo, "$0.00"},
 
 		// max GPT-3.5 context x 100 messages
 		{3328_00, 768_00, ModelChatGPT4, "$14.59"},
 		{3840_00, 256_00, ModelChatGPT4, "$13.06"},
-		{3328_00, 768_00, ModelChatGPT35Turbo, "$0.82"},
-		{3840_00, 256_00, ModelChatGPT35Turbo, "$0.82"},
+		{3328_00, 768_00, ModelChatGPT35Turbo, "$0.28"},
+		{3840_00, 256_00, ModelChatGPT35Turbo, "$0.23"},
 
 		// less-than-max-GPT-3.5 context
 		{2000, 768, ModelChatGPT4, "$0.11"},
-		{2000, 768, ModelChatGPT35Turbo, "$0.01"},
+		{2000, 768, ModelChatGPT35Turbo, "$0.00"},
 		{1500, 768, ModelChatGPT4, "$0.09"},
 		{1500, 768, ModelChatGPT35Turbo, "$0.00"},
 		{1000, 768, ModelChatGPT4, "$0.08"},
 		{1000, 768, ModelChatGPT35Turbo, "$0.00"},
 
 		{2000, 512, ModelChatGPT4, "$0.09"},
-		{2000, 512, ModelChatGPT35Turbo, "$0.01"},
+		{2000, 512, ModelChatGPT35Turbo, "$0.00"},
 		{1500, 512, ModelChatGPT4, "$0.08"},
 		{1500, 512, ModelChatGPT35Turbo, "$0.00"},
 		{1000, 512, ModelChatGPT4, "$0.06"},
@@ -69,12 +72,12 @@ func TestCost(t *testing.T) {
 		{1000, 256, ModelChatGPT35Turbo, "$0.00"},
 
 		// random large example
-		{2000_00, 768_00, ModelChatGPT35Turbo, "$0.55"},
-		{2000_00, 512_00, ModelChatGPT35Turbo, "$0.50"},
-		{2000_00, 256_00, ModelChatGPT35Turbo, "$0.45"},
+		{2000_00, 768_00, ModelChatGPT35Turbo, "$0.22"},
+		{2000_00, 512_00, ModelChatGPT35Turbo, "$0.18"},
+		{2000_00, 256_00, ModelChatGPT35Turbo, "$0.14"},
 		{1_000_000, 100_000, ModelChatGPT4, "$36.00"},
 		{1_000_000, 100_000, ModelChatGPT4With32k, "$72.00"},
-		{1_000_000, 100_000, ModelChatGPT35Turbo, "$2.20"},
+		{1_000_000, 100_000, ModelChatGPT35Turbo, "$0.65"},
 		{1_000_000, 100_000, fine, "$132.00"},
 
 		// {0, 1000000, "$2.00", "$60.00", "$20.00", "$120.00"},
@@ -85,3 +88,144 @@ func TestCost(t *testing.T) {
 		}
 	}
 }
+
+func TestLookupModel(t *testing.T) {
+	tests := []struct {
+		model         string
+		contextWindow int
+		err           string
+	}{
+		{ModelChatGPT4o, 128000, ""},
+		{"gpt-4o-2024-05-13", 128000, ""},
+		{"gpt-3.5-turbo-0613", 4096, ""},
+		{"curie:ft-acme-2023-03-01", 2048, ""},
+		{"gpt-5-ultra", 0, `unknown model "gpt-5-ultra"`},
+		{"foo:ft-acme", 0, `unknown model "foo" in "foo:ft-acme"`},
+	}
+	for _, test := range tests {
+		info, err := LookupModel(test.model)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err || !errors.Is(err, ErrUnknownModel) {
+				t.Errorf("** LookupModel(%q) error = %v, wanted %s", test.model, err, test.err)
+			}
+		} else if err != nil || info.ContextWindow != test.contextWindow {
+			t.Errorf("** LookupModel(%q) = %d, %v, wanted %d", test.model, info.ContextWindow, err, test.contextWindow)
+		}
+	}
+
+	const custom = "test-custom-model"
+	if _, err := CostE(1000, 1000, custom); err == nil {
+		t.Errorf("** CostE(%q) succeeded before registration", custom)
+	}
+	RegisterModel(ModelInfo{Name: custom, ContextWindow: 1000, InputPrice: usdPerM(1.00), OutputPrice: usdPerM(2.00)})
+	if a := Cost(1_000_000, 1_000_000, custom).String(); a != "$3.00" {
+		t.Errorf("** Cost(%q) = %s, wanted $3.00", custom, a)
+	}
+	if a := MaxTokens(custom); a != 1000 {
+		t.Errorf("** MaxTokens(%q) = %d, wanted 1000", custom, a)
+	}
+}
+
+func TestFineTuningCost(t *testing.T) {
+	if a := FineTuningCost(1_000_000, "davinci").String(); a != "$30.00" {
+		t.Errorf("** FineTuningCost(davinci) = %s, wanted $30.00", a)
+	}
+	if a := FineTuningCost(1_000_000, "ada:ft-acme").String(); a != "$0.40" {
+		t.Errorf("** FineTuningCost(ada:ft-acme) = %s, wanted $0.40", a)
+	}
+	if _, err := FineTuningCostE(1000, ModelChatGPT4); err == nil {
+		t.Errorf("** FineTuningCostE(gpt-4) succeeded, wanted error")
+	}
+}
+
+func TestUsageCost(t *testing.T) {
+	usage := Usage{
+		PromptTokens:            1_000_000,
+		CompletionTokens:        1_000_000,
+		PromptTokensDetails:     PromptTokensDetails{CachedTokens: 400_000},
+		CompletionTokensDetails: CompletionTokensDetails{ReasoningTokens: 250_000},
+	}
+	c, err := UsageCost(usage, ModelO1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := CostBreakdown{Input: usdPerM(9.00), CachedInput: usdPerM(3.00), Output: usdPerM(45.00), Reasoning: usdPerM(15.00)}
+	if c != expected || c.Total().String() != "$72.00" {
+		t.Errorf("** UsageCost = %+v (total %v), wanted %+v", c, c.Total(), expected)
+	}
+
+	usage = Usage{
+		PromptTokens:            1_000_000,
+		CompletionTokens:        1_000_000,
+		PromptTokensDetails:     PromptTokensDetails{AudioTokens: 500_000},
+		CompletionTokensDetails: CompletionTokensDetails{AudioTokens: 500_000},
+	}
+	if _, err := UsageCost(usage, ModelChatGPT4o); err == nil {
+		t.Errorf("** UsageCost of audio tokens with a text model succeeded, wanted error")
+	}
+	c, err = UsageCost(usage, "gpt-4o-audio-preview")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := c.Total().String(); a != "$66.25" {
+		t.Errorf("** UsageCost(audio).Total() = %s, wanted $66.25", a)
+	}
+}
+
+func TestModelResolution(t *testing.T) {
+	tests := []struct {
+		model     string
+		base      string
+		maxTokens int
+		cost      string // of 1M prompt + 1M completion tokens
+	}{
+		{"gpt-4.1-2025-04-14", "gpt-4.1-2025-04-14", 1_047_576, "$10.00"},
+		{"gpt-4.1-mini-2025-04-14", "gpt-4.1-mini-2025-04-14", 1_047_576, "$2.00"},
+		{"o1-2024-12-17", "o1-2024-12-17", 200_000, "$75.00"},
+		{"gpt-4o-2024-05-13", "gpt-4o-2024-05-13", 128_000, "$20.00"},
+		{"chatgpt-4o-latest", "chatgpt-4o-latest", 128_000, "$20.00"},
+		{"gpt-4o-mini-2024-07-18", "gpt-4o-mini-2024-07-18", 128_000, "$0.75"},
+		{"gpt-4o-audio-preview-2024-12-17", "gpt-4o-audio-preview-2024-12-17", 128_000, "$12.50"},
+		{"gpt-4-turbo-2024-04-09", "gpt-4-turbo-2024-04-09", 128_000, "$40.00"},
+		{"gpt-4-0613", "gpt-4-0613", 8192, "$90.00"},
+		{"gpt-4-32k-0613", "gpt-4-32k-0613", 32_768, "$180.00"},
+		{"gpt-3.5-turbo-0125", "gpt-3.5-turbo-0125", 4096, "$2.00"},
+		{"ft:gpt-4o-mini-2024-07-18:acme::abc123", "gpt-4o-mini-2024-07-18", 128_000, "$1.50"},
+		{"ft:gpt-3.5-turbo-0613:acme:custom-suffix:abc123", "gpt-3.5-turbo-0613", 4096, "$9.00"},
+		{"davinci:ft-acme-2023-03-01-12-00-00", "davinci", 2048, "$240.00"},
+	}
+	for _, test := range tests {
+		if a := BaseModel(test.model); a != test.base {
+			t.Errorf("** BaseModel(%q) = %q, wanted %q", test.model, a, test.base)
+		}
+		if a, err := MaxTokensE(test.model); err != nil || a != test.maxTokens {
+			t.Errorf("** MaxTokensE(%q) = %d, %v, wanted %d", test.model, a, err, test.maxTokens)
+		}
+		if a, err := CostE(1_000_000, 1_000_000, test.model); err != nil || a.String() != test.cost {
+			t.Errorf("** CostE(%q) = %v, %v, wanted %s", test.model, a, err, test.cost)
+		}
+	}
+
+	if a := FineTuningCost(1_000_000, "ft:gpt-4o-mini-2024-07-18:acme::abc123").String(); a != "$3.00" {
+		t.Errorf("** FineTuningCost(ft:gpt-4o-mini) = %s, wanted $3.00", a)
+	}
+	if a, err := ModelEncoding("ft:gpt-4o-mini-2024-07-18:acme::abc123"); err != nil || a != EncodingO200k {
+		t.Errorf("** ModelEncoding(ft:gpt-4o-mini) = %q, %v, wanted %q", a, err, EncodingO200k)
+	}
+	if _, err := MaxTokensE("gpt-5-2025-01-01"); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("** MaxTokensE(gpt-5 snapshot) error = %v, wanted ErrUnknownModel", err)
+	}
+	if _, err := MaxTokensE("ft:gpt-5:acme::abc"); err == nil || err.Error() != `unknown model "gpt-5" in "ft:gpt-5:acme::abc"` {
+		t.Errorf("** MaxTokensE(ft:gpt-5) error = %v", err)
+	}
+
+	RegisterModelAlias("test-alias-a", "test-alias-b")
+	RegisterModelAlias("test-alias-b", "test-alias-a")
+	if _, err := MaxTokensE("test-alias-a"); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("** MaxTokensE of cyclic alias error = %v, wanted ErrUnknownModel", err)
+	}
+	RegisterModelAlias("test-deployment", "gpt-4o-2024-05-13")
+	if a := MaxTokens("test-deployment"); a != 128_000 {
+		t.Errorf("** MaxTokens(test-deployment) = %d, wanted 128000", a)
+	}
+}
@@ -29,11 +29,20 @@ func Complete(ctx context.Context, prompt string, opt Options, client *http.Clie
 		Options: opt,
 	}
 
+	tc, err := trackCall(ctx, callID, opt.Model, opt.N, func() (int, int) {
+		return TokenCount(prompt, opt.Model), opt.MaxTokens
+	})
+	if err != nil {
+		return nil, Usage{}, err
+	}
+
 	var resp completionResponse
-	err := post(ctx, callID, "https://api.openai.com/v1/completions", client, creds, req, &resp)
+	err = post(ctx, callID, "https://api.openai.com/v1/completions", client, creds, req, &resp)
 	if err != nil {
+		tc.finish(nil)
 		return nil, Usage{}, err
 	}
+	tc.finish(&resp.Usage)
 	if len(resp.Choices) == 0 {
 		return nil, resp.Usage, &Error{
 			CallID:  callID,
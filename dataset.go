@@ -0,0 +1,181 @@
+package openai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	// minFineTuningExamples is the smallest dataset the fine-tuning API accepts.
+	minFineTuningExamples = 10
+
+	// defaultFineTuningEpochs is assumed when estimating costs without an explicit epoch count.
+	defaultFineTuningEpochs = 3
+)
+
+// FineTuningExample is a single conversation of a chat fine-tuning dataset.
+type FineTuningExample struct {
+	Msgs      []Msg `json:"messages"`
+	Functions []any `json:"functions,omitempty"`
+	Tools     []any `json:"tools,omitempty"`
+}
+
+// FineTuningDatasetWriter writes examples in the JSONL format expected by chat fine-tuning,
+// one example per line.
+type FineTuningDatasetWriter struct {
+	w io.Writer
+	n int
+}
+
+// NewFineTuningDatasetWriter returns a writer producing a fine-tuning dataset into w.
+func NewFineTuningDatasetWriter(w io.Writer) *FineTuningDatasetWriter {
+	return &FineTuningDatasetWriter{w: w}
+}
+
+// Write adds an example to the dataset.
+func (dw *FineTuningDatasetWriter) Write(example FineTuningExample) error {
+	_, err := dw.w.Write(saneMarshal(&example)) // includes trailing newline
+	if err == nil {
+		dw.n++
+	}
+	return err
+}
+
+// Count returns the number of examples written so far.
+func (dw *FineTuningDatasetWriter) Count() int {
+	return dw.n
+}
+
+// ReadFineTuningDataset parses a JSONL fine-tuning dataset, e.g. to validate it
+// with ValidateFineTuningDataset before uploading.
+func ReadFineTuningDataset(r io.Reader) ([]FineTuningExample, error) {
+	var result []FineTuningExample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+		var example FineTuningExample
+		if err := json.Unmarshal(raw, &example); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		result = append(result, example)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FineTuningDatasetReport is the result of ValidateFineTuningDataset.
+type FineTuningDatasetReport struct {
+	Examples         int
+	Tokens           int // per epoch
+	MaxExampleTokens int
+	Epochs           int
+	EstimatedCost    Price
+	Problems         []FineTuningDatasetProblem
+}
+
+// FineTuningDatasetProblem is a reason the fine-tuning API would reject a dataset.
+type FineTuningDatasetProblem struct {
+	Example int // 1-based index of the example, 0 for problems with the dataset as a whole
+	Message string
+}
+
+func (p FineTuningDatasetProblem) String() string {
+	if p.Example == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("example %d: %s", p.Example, p.Message)
+}
+
+// ValidateFineTuningDataset checks that examples are suitable for fine-tuning the given model,
+// and estimates the cost of training for the given number of epochs (0 assumes 3).
+// Each example must fit into the context window of the model.
+// Returns an error only if the model is unknown or cannot be fine-tuned; problems
+// with the examples are listed in the report.
+func ValidateFineTuningDataset(examples []FineTuningExample, model string, epochs int) (*FineTuningDatasetReport, error) {
+	info, err := LookupModel(model)
+	if err != nil {
+		return nil, err
+	}
+	if epochs <= 0 {
+		epochs = defaultFineTuningEpochs
+	}
+	report := &FineTuningDatasetReport{
+		Examples: len(examples),
+		Epochs:   epochs,
+	}
+	if len(examples) < minFineTuningExamples {
+		report.Problems = append(report.Problems, FineTuningDatasetProblem{0, fmt.Sprintf("at least %d examples are required, got %d", minFineTuningExamples, len(examples))})
+	}
+
+	for i, example := range examples {
+		problem := func(format string, args ...any) {
+			report.Problems = append(report.Problems, FineTuningDatasetProblem{i + 1, fmt.Sprintf(format, args...)})
+		}
+
+		if len(example.Msgs) == 0 {
+			problem("no messages")
+			continue
+		}
+		var hasAssistant bool
+		pendingCalls := make(map[string]bool) // tool calls not answered yet
+		for j, msg := range example.Msgs {
+			switch msg.Role {
+			case System, User:
+				if msg.FunctionCall != nil || len(msg.ToolCalls) > 0 {
+					problem("message %d: function call in a %s message", j+1, msg.Role)
+				}
+			case Assistant:
+				hasAssistant = true
+				if msg.Content == "" && msg.FunctionCall == nil && len(msg.ToolCalls) == 0 {
+					problem("message %d: assistant message has neither content nor function call", j+1)
+				}
+				for _, call := range msg.ToolCalls {
+					if call.ID == "" || call.Function.Name == "" {
+						problem("message %d: tool call has no ID or function name", j+1)
+					} else {
+						pendingCalls[call.ID] = true
+					}
+				}
+			case Function:
+				if msg.Name == "" {
+					problem("message %d: function message has no name", j+1)
+				}
+			case Tool:
+				if !pendingCalls[msg.ToolCallID] {
+					problem("message %d: tool message does not answer a preceding tool call (tool_call_id %q)", j+1, msg.ToolCallID)
+				}
+				delete(pendingCalls, msg.ToolCallID)
+			default:
+				problem("message %d: invalid role %q", j+1, msg.Role)
+			}
+		}
+		if !hasAssistant {
+			problem("no assistant messages")
+		}
+
+		n := ChatPromptTokenCount(example.Msgs, Options{Model: model, Functions: example.Functions, Tools: example.Tools})
+		if n > info.ContextWindow {
+			problem("%d tokens exceed the limit of %d", n, info.ContextWindow)
+		}
+		report.Tokens += n
+		if n > report.MaxExampleTokens {
+			report.MaxExampleTokens = n
+		}
+	}
+
+	report.EstimatedCost, err = FineTuningCostE(report.Tokens*epochs, model)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
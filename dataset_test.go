@@ -0,0 +1,96 @@
+package openai
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFineTuningDataset(t *testing.T) {
+	weather := map[string]any{"name": "get_weather", "parameters": map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}}}
+	examples := []FineTuningExample{
+		{Msgs: []Msg{SystemMsg("You are a bot."), UserMsg("Hello, world."), AssistantMsg("Hi!")}},
+		{
+			Msgs: []Msg{
+				UserMsg("Weather in Paris?"),
+				{Role: Assistant, FunctionCall: &FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				{Role: Function, Name: "get_weather", Content: "sunny"},
+				AssistantMsg("It's sunny."),
+			},
+			Functions: []any{weather},
+		},
+		{
+			Msgs: []Msg{
+				UserMsg("Weather in Paris and Rome?"),
+				{Role: Assistant, ToolCalls: []ToolCall{
+					{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+					{ID: "call_2", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Rome"}`}},
+				}},
+				{Role: Tool, ToolCallID: "call_1", Content: "sunny"},
+				{Role: Tool, ToolCallID: "call_2", Content: "rainy"},
+				AssistantMsg("Sunny in Paris, rainy in Rome."),
+			},
+			Tools: []any{map[string]any{"type": "function", "function": weather}},
+		},
+	}
+
+	var buf bytes.Buffer
+	w := NewFineTuningDatasetWriter(&buf)
+	for _, example := range examples {
+		if err := w.Write(example); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Count() != 3 || strings.Count(buf.String(), "\n") != 3 {
+		t.Fatalf("** wrote %d examples:\n%s", w.Count(), buf.String())
+	}
+	if line, _, _ := strings.Cut(buf.String(), "\n"); line != `{"messages":[{"role":"system","content":"You are a bot."},{"role":"user","content":"Hello, world."},{"role":"assistant","content":"Hi!"}]}` {
+		t.Errorf("** first line = %s", line)
+	}
+
+	read, err := ReadFineTuningDataset(&buf)
+	if err != nil || len(read) != 3 || read[1].Msgs[1].FunctionCall.Name != "get_weather" || len(read[1].Functions) != 1 {
+		t.Fatalf("** ReadFineTuningDataset = %+v, %v", read, err)
+	}
+	if !reflect.DeepEqual(read[2], examples[2]) {
+		t.Errorf("** tool calls example read back as %+v", read[2])
+	}
+
+	read = append(read,
+		FineTuningExample{Msgs: []Msg{UserMsg("Hi")}},
+		FineTuningExample{Msgs: []Msg{{Role: "bot", Content: "Hi"}, {Role: Assistant}}},
+		FineTuningExample{Msgs: []Msg{
+			UserMsg("Weather?"),
+			{Role: Assistant, ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{}`}}}},
+			{Role: Tool, ToolCallID: "call_1", Content: "sunny"},
+			{Role: Tool, ToolCallID: "call_1", Content: "sunny"},
+			{Role: Tool, ToolCallID: "call_9", Content: "rainy"},
+			AssistantMsg("Sunny."),
+		}},
+	)
+	report, err := ValidateFineTuningDataset(read, ModelChatGPT4oMini, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var problems []string
+	for _, p := range report.Problems {
+		problems = append(problems, p.String())
+	}
+	expected := "at least 10 examples are required, got 6 | example 4: no assistant messages | example 5: message 1: invalid role \"bot\" | example 5: message 2: assistant message has neither content nor function call" +
+		" | example 6: message 4: tool message does not answer a preceding tool call (tool_call_id \"call_1\")" +
+		" | example 6: message 5: tool message does not answer a preceding tool call (tool_call_id \"call_9\")"
+	if a := strings.Join(problems, " | "); a != expected {
+		t.Errorf("** problems = %s, wanted %s", a, expected)
+	}
+	if report.Examples != 6 || report.Epochs != 2 || report.Tokens == 0 || report.MaxExampleTokens == 0 {
+		t.Errorf("** report = %+v", report)
+	}
+	if a, e := report.EstimatedCost, FineTuningCost(report.Tokens*2, ModelChatGPT4oMini); a != e {
+		t.Errorf("** EstimatedCost = %v, wanted %v", a, e)
+	}
+
+	if _, err := ValidateFineTuningDataset(read, ModelChatGPT4, 0); err == nil {
+		t.Errorf("** ValidateFineTuningDataset for a model that cannot be fine-tuned succeeded")
+	}
+}
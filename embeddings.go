@@ -13,11 +13,20 @@ func ComputeEmbedding(ctx context.Context, input string, client *http.Client, cr
 		Input: input,
 	}
 
+	tc, err := trackCall(ctx, callID, req.Model, 1, func() (int, int) {
+		return TokenCount(input, req.Model), 0
+	})
+	if err != nil {
+		return nil, Usage{}, err
+	}
+
 	var resp embeddingsResponse
-	err := post(ctx, callID, "https://api.openai.com/v1/embeddings", client, creds, req, &resp)
+	err = post(ctx, callID, "https://api.openai.com/v1/embeddings", client, creds, req, &resp)
 	if err != nil {
+		tc.finish(nil)
 		return nil, Usage{}, err
 	}
+	tc.finish(&resp.Usage)
 	if len(resp.Data) == 0 {
 		return nil, Usage{}, &Error{
 			CallID:  callID,
@@ -0,0 +1,100 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type FilePurpose string
+
+const (
+	FilePurposeAssistants FilePurpose = "assistants"
+	FilePurposeBatch      FilePurpose = "batch"
+	FilePurposeFineTune   FilePurpose = "fine-tune"
+	FilePurposeVision     FilePurpose = "vision"
+	FilePurposeUserData   FilePurpose = "user_data"
+
+	// These are only set by OpenAI on output files.
+	FilePurposeBatchOutput    FilePurpose = "batch_output"
+	FilePurposeFineTuneResult FilePurpose = "fine-tune-results"
+)
+
+// File is an uploaded file.
+type File struct {
+	ID        string      `json:"id"`
+	Bytes     int64       `json:"bytes"`
+	CreatedAt int64       `json:"created_at"`
+	ExpiresAt int64       `json:"expires_at"`
+	Filename  string      `json:"filename"`
+	Purpose   FilePurpose `json:"purpose"`
+}
+
+type deleteResponse struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// UploadFile uploads the contents of r under the given file name. The data is streamed
+// as it's being uploaded, so r can be arbitrarily large (up to the API limits).
+func UploadFile(ctx context.Context, r io.Reader, filename string, purpose FilePurpose, client *http.Client, creds Credentials) (*File, error) {
+	const callID = "UploadFile"
+	body := multipartBody([]formField{{"purpose", string(purpose)}}, []formFile{{"file", filename, r}})
+	var resp File
+	err := post(ctx, callID, "https://api.openai.com/v1/files", client, creds, body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListFiles returns a page of uploaded files, optionally only those with the given purpose,
+// and whether there are more pages.
+func ListFiles(ctx context.Context, purpose FilePurpose, opt ListOptions, client *http.Client, creds Credentials) ([]*File, bool, error) {
+	const callID = "ListFiles"
+	endpoint := opt.query("https://api.openai.com/v1/files")
+	if purpose != "" {
+		endpoint = appendQuery(endpoint, "purpose", string(purpose))
+	}
+	var resp listResponse[*File]
+	err := get(ctx, callID, endpoint, client, creds, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Data, resp.HasMore, nil
+}
+
+// RetrieveFile returns information about an uploaded file.
+func RetrieveFile(ctx context.Context, fileID string, client *http.Client, creds Credentials) (*File, error) {
+	const callID = "RetrieveFile"
+	var resp File
+	err := get(ctx, callID, "https://api.openai.com/v1/files/"+url.PathEscape(fileID), client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DownloadFile copies the contents of an uploaded or generated file into w.
+func DownloadFile(ctx context.Context, fileID string, w io.Writer, client *http.Client, creds Credentials) error {
+	const callID = "DownloadFile"
+	return get(ctx, callID, "https://api.openai.com/v1/files/"+url.PathEscape(fileID)+"/content", client, creds, w)
+}
+
+// DeleteFile deletes an uploaded file.
+func DeleteFile(ctx context.Context, fileID string, client *http.Client, creds Credentials) error {
+	const callID = "DeleteFile"
+	var resp deleteResponse
+	err := del(ctx, callID, "https://api.openai.com/v1/files/"+url.PathEscape(fileID), client, creds, &resp)
+	if err != nil {
+		return err
+	}
+	if !resp.Deleted {
+		return &Error{
+			CallID:  callID,
+			Message: "file was not deleted",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	const content = `{"messages": []}` + "\n"
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+			if err := r.ParseMultipartForm(1024); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			f, fh, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(f)
+			if string(data) != content || fh.Filename != "train.jsonl" || r.FormValue("purpose") != "fine-tune" {
+				http.Error(w, "unexpected upload", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"id": "file-1", "bytes": 17, "filename": "train.jsonl", "purpose": "fine-tune"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/files":
+			if r.URL.RawQuery != "limit=5&purpose=batch" {
+				http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"data": [{"id": "file-2", "purpose": "batch"}], "has_more": false}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/files/file-1/content":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte(content))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/files/file-1":
+			w.Write([]byte(`{"id": "file-1", "object": "file", "deleted": true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	f, err := UploadFile(ctx, strings.NewReader(content), "train.jsonl", FilePurposeFineTune, client, Credentials{})
+	if err != nil || f.ID != "file-1" || f.Bytes != 17 {
+		t.Fatalf("** UploadFile = %+v, %v", f, err)
+	}
+
+	files, more, err := ListFiles(ctx, FilePurposeBatch, ListOptions{Limit: 5}, client, Credentials{})
+	if err != nil || len(files) != 1 || more || files[0].Purpose != FilePurposeBatch {
+		t.Errorf("** ListFiles = %v, %v, %v", files, more, err)
+	}
+
+	var buf bytes.Buffer
+	if err := DownloadFile(ctx, "file-1", &buf, client, Credentials{}); err != nil || buf.String() != content {
+		t.Errorf("** DownloadFile = %q, %v", buf.String(), err)
+	}
+
+	if err := DeleteFile(ctx, "file-1", client, Credentials{}); err != nil {
+		t.Errorf("** DeleteFile: %v", err)
+	}
+	if err := DeleteFile(ctx, "file-404", client, Credentials{}); err == nil {
+		t.Errorf("** DeleteFile of missing file succeeded")
+	}
+}
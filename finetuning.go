@@ -0,0 +1,166 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// FineTuningJobParams describes a fine-tuning job to create.
+type FineTuningJobParams struct {
+	// Model is the base model to fine-tune, e.g. ModelChatGPT4oMini.
+	Model string `json:"model"`
+
+	// TrainingFile is the ID of an uploaded JSONL file with purpose "fine-tune".
+	TrainingFile string `json:"training_file"`
+
+	// ValidationFile is the optional ID of an uploaded JSONL file with validation data.
+	ValidationFile string `json:"validation_file,omitempty"`
+
+	Hyperparameters *FineTuningHyperparameters `json:"hyperparameters,omitempty"`
+
+	// Suffix is up to 64 characters to include into the fine-tuned model name.
+	Suffix string `json:"suffix,omitempty"`
+
+	Seed     int               `json:"seed,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// FineTuningHyperparameters are the hyperparameters of a fine-tuning job.
+// Each one is either "auto" or a number; leave nil to use the default.
+type FineTuningHyperparameters struct {
+	NEpochs                any `json:"n_epochs,omitempty"`
+	BatchSize              any `json:"batch_size,omitempty"`
+	LearningRateMultiplier any `json:"learning_rate_multiplier,omitempty"`
+}
+
+type FineTuningJobStatus string
+
+const (
+	FineTuningValidatingFiles FineTuningJobStatus = "validating_files"
+	FineTuningQueued          FineTuningJobStatus = "queued"
+	FineTuningRunning         FineTuningJobStatus = "running"
+	FineTuningSucceeded       FineTuningJobStatus = "succeeded"
+	FineTuningFailed          FineTuningJobStatus = "failed"
+	FineTuningCancelled       FineTuningJobStatus = "cancelled"
+)
+
+// IsFinal returns whether the job has stopped and its status won't change any more.
+func (s FineTuningJobStatus) IsFinal() bool {
+	return s == FineTuningSucceeded || s == FineTuningFailed || s == FineTuningCancelled
+}
+
+// FineTuningJob is the state of a fine-tuning job.
+type FineTuningJob struct {
+	ID              string                    `json:"id"`
+	CreatedAt       int64                     `json:"created_at"`
+	FinishedAt      int64                     `json:"finished_at"`
+	EstimatedFinish int64                     `json:"estimated_finish"`
+	Model           string                    `json:"model"`
+	FineTunedModel  string                    `json:"fine_tuned_model"` // empty until the job succeeds
+	OrganizationID  string                    `json:"organization_id"`
+	Status          FineTuningJobStatus       `json:"status"`
+	Hyperparameters FineTuningHyperparameters `json:"hyperparameters"`
+	TrainingFile    string                    `json:"training_file"`
+	ValidationFile  string                    `json:"validation_file"`
+	ResultFiles     []string                  `json:"result_files"`
+	TrainedTokens   int                       `json:"trained_tokens"`
+	Seed            int                       `json:"seed"`
+	Error           *FineTuningJobError       `json:"error"`
+}
+
+// FineTuningJobError explains why a fine-tuning job has failed.
+type FineTuningJobError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param"`
+}
+
+// FineTuningEvent is a log entry of a fine-tuning job.
+type FineTuningEvent struct {
+	ID        string `json:"id"`
+	CreatedAt int64  `json:"created_at"`
+	Level     string `json:"level"` // "info", "warn" or "error"
+	Message   string `json:"message"`
+	Type      string `json:"type"` // "message" or "metrics"
+	Data      any    `json:"data"`
+}
+
+// FineTuningCheckpoint is an intermediate model produced by a fine-tuning job.
+type FineTuningCheckpoint struct {
+	ID                       string             `json:"id"`
+	CreatedAt                int64              `json:"created_at"`
+	FineTunedModelCheckpoint string             `json:"fine_tuned_model_checkpoint"`
+	FineTuningJobID          string             `json:"fine_tuning_job_id"`
+	StepNumber               int                `json:"step_number"`
+	Metrics                  map[string]float64 `json:"metrics"`
+}
+
+// CreateFineTuningJob starts fine-tuning a model.
+func CreateFineTuningJob(ctx context.Context, params FineTuningJobParams, client *http.Client, creds Credentials) (*FineTuningJob, error) {
+	const callID = "CreateFineTuningJob"
+	var resp FineTuningJob
+	err := post(ctx, callID, "https://api.openai.com/v1/fine_tuning/jobs", client, creds, &params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListFineTuningJobs returns a page of fine-tuning jobs of the organization, newest first,
+// and whether there are more pages.
+func ListFineTuningJobs(ctx context.Context, opt ListOptions, client *http.Client, creds Credentials) ([]*FineTuningJob, bool, error) {
+	const callID = "ListFineTuningJobs"
+	var resp listResponse[*FineTuningJob]
+	err := get(ctx, callID, opt.query("https://api.openai.com/v1/fine_tuning/jobs"), client, creds, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Data, resp.HasMore, nil
+}
+
+// RetrieveFineTuningJob returns the current state of a fine-tuning job.
+func RetrieveFineTuningJob(ctx context.Context, jobID string, client *http.Client, creds Credentials) (*FineTuningJob, error) {
+	const callID = "RetrieveFineTuningJob"
+	var resp FineTuningJob
+	err := get(ctx, callID, "https://api.openai.com/v1/fine_tuning/jobs/"+url.PathEscape(jobID), client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CancelFineTuningJob cancels a running fine-tuning job.
+func CancelFineTuningJob(ctx context.Context, jobID string, client *http.Client, creds Credentials) (*FineTuningJob, error) {
+	const callID = "CancelFineTuningJob"
+	var resp FineTuningJob
+	err := post(ctx, callID, "https://api.openai.com/v1/fine_tuning/jobs/"+url.PathEscape(jobID)+"/cancel", client, creds, struct{}{}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListFineTuningEvents returns a page of events of a fine-tuning job, newest first,
+// and whether there are more pages.
+func ListFineTuningEvents(ctx context.Context, jobID string, opt ListOptions, client *http.Client, creds Credentials) ([]*FineTuningEvent, bool, error) {
+	const callID = "ListFineTuningEvents"
+	var resp listResponse[*FineTuningEvent]
+	err := get(ctx, callID, opt.query("https://api.openai.com/v1/fine_tuning/jobs/"+url.PathEscape(jobID)+"/events"), client, creds, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Data, resp.HasMore, nil
+}
+
+// ListFineTuningCheckpoints returns a page of checkpoints of a fine-tuning job, newest first,
+// and whether there are more pages.
+func ListFineTuningCheckpoints(ctx context.Context, jobID string, opt ListOptions, client *http.Client, creds Credentials) ([]*FineTuningCheckpoint, bool, error) {
+	const callID = "ListFineTuningCheckpoints"
+	var resp listResponse[*FineTuningCheckpoint]
+	err := get(ctx, callID, opt.query("https://api.openai.com/v1/fine_tuning/jobs/"+url.PathEscape(jobID)+"/checkpoints"), client, creds, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Data, resp.HasMore, nil
+}
@@ -0,0 +1,74 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestFineTuningJobs(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/fine_tuning/jobs":
+			if r.Method == http.MethodPost {
+				w.Write([]byte(`{"id": "ftjob-1", "model": "gpt-4o-mini-2024-07-18", "status": "validating_files", "fine_tuned_model": null, "hyperparameters": {"n_epochs": "auto"}}`))
+			} else {
+				w.Write([]byte(`{"object": "list", "data": [{"id": "ftjob-1", "status": "running"}], "has_more": true}`))
+			}
+		case "/v1/fine_tuning/jobs/ftjob-1/cancel":
+			w.Write([]byte(`{"id": "ftjob-1", "status": "cancelled"}`))
+		case "/v1/fine_tuning/jobs/ftjob-1/events":
+			w.Write([]byte(`{"object": "list", "data": [{"id": "ftevent-1", "level": "info", "message": "Job started"}], "has_more": false}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	job, err := CreateFineTuningJob(ctx, FineTuningJobParams{
+		Model:           ModelChatGPT4oMini,
+		TrainingFile:    "file-abc",
+		Hyperparameters: &FineTuningHyperparameters{NEpochs: 3},
+	}, client, Credentials{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != "ftjob-1" || job.Status != FineTuningValidatingFiles || job.Status.IsFinal() || job.Hyperparameters.NEpochs != "auto" {
+		t.Errorf("** CreateFineTuningJob = %+v", job)
+	}
+
+	jobs, more, err := ListFineTuningJobs(ctx, ListOptions{After: "ftjob-0", Limit: 10}, client, Credentials{})
+	if err != nil || len(jobs) != 1 || !more || jobs[0].Status != FineTuningRunning {
+		t.Errorf("** ListFineTuningJobs = %v, %v, %v", jobs, more, err)
+	}
+
+	job, err = CancelFineTuningJob(ctx, "ftjob-1", client, Credentials{})
+	if err != nil || !job.Status.IsFinal() {
+		t.Errorf("** CancelFineTuningJob = %+v, %v", job, err)
+	}
+
+	events, more, err := ListFineTuningEvents(ctx, "ftjob-1", ListOptions{}, client, Credentials{})
+	if err != nil || len(events) != 1 || more || events[0].Message != "Job started" {
+		t.Errorf("** ListFineTuningEvents = %v, %v, %v", events, more, err)
+	}
+
+	expected := []string{
+		`POST /v1/fine_tuning/jobs {"model":"gpt-4o-mini","training_file":"file-abc","hyperparameters":{"n_epochs":3}}` + "\n",
+		`GET /v1/fine_tuning/jobs?after=ftjob-0&limit=10 `,
+		`POST /v1/fine_tuning/jobs/ftjob-1/cancel {}` + "\n",
+		`GET /v1/fine_tuning/jobs/ftjob-1/events `,
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("** got %d requests, wanted %d: %q", len(requests), len(expected), requests)
+	}
+	for i, e := range expected {
+		if requests[i] != e {
+			t.Errorf("** request %d = %q, wanted %q", i, requests[i], e)
+		}
+	}
+}
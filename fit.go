@@ -1,5 +1,10 @@
 package openai
 
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
 // FitChatContext returns messages that fit into maxTokenCount, skipping those that don't
 // fit. This is meant for including knowledge base context into ChatGPT prompts.
 func FitChatContext(candidates []Msg, maxTokenCount int, model string) ([]Msg, int) {
@@ -23,7 +28,7 @@ func FitChatContext(candidates []Msg, maxTokenCount int, model string) ([]Msg, i
 
 func DropChatHistoryIfNeeded(chat []Msg, fixedSuffixLen int, maxTokens int, model string) ([]Msg, int) {
 	msgTokens := make([]int, len(chat))
-	usedTokens := chatTokenOverhead
+	usedTokens := chatReplyPrimingTokens
 	for i, msg := range chat {
 		c := MsgTokenCount(msg, model) // this is by far the slowest op here; cache result to avoid calling twice
 		msgTokens[i] = c
@@ -45,3 +50,125 @@ func DropChatHistoryIfNeeded(chat []Msg, fixedSuffixLen int, maxTokens int, mode
 		return chat, usedTokens
 	}
 }
+
+// TruncateTokens returns the longest prefix of text that fits into maxTokens,
+// followed by ellipsis if anything was cut. The ellipsis (e.g. "…") counts towards
+// maxTokens. The text is cut at a token boundary that does not split a character.
+func TruncateTokens(text string, maxTokens int, ellipsis, model string) string {
+	tokens, offsets := encodeWithOffsets(text, model)
+	if len(tokens) <= maxTokens {
+		return text
+	}
+	for n := maxTokens - TokenCount(ellipsis, model); n >= 0; n-- {
+		n = charBoundaryBefore(text, offsets, n)
+		result := text[:offsets[n]] + ellipsis
+		if TokenCount(result, model) <= maxTokens {
+			return result // almost always on the first iteration, unless ellipsis merges with the text
+		}
+	}
+	return ""
+}
+
+// TruncateTokensFromStart returns the longest suffix of text that fits into maxTokens,
+// preceded by ellipsis if anything was cut. The ellipsis counts towards maxTokens.
+// The text is cut at a token boundary that does not split a character.
+func TruncateTokensFromStart(text string, maxTokens int, ellipsis, model string) string {
+	tokens, offsets := encodeWithOffsets(text, model)
+	if len(tokens) <= maxTokens {
+		return text
+	}
+	for n := maxTokens - TokenCount(ellipsis, model); n >= 0; n-- {
+		start := charBoundaryAfter(text, offsets, len(tokens)-n)
+		n = len(tokens) - start
+		result := ellipsis + text[offsets[start]:]
+		if TokenCount(result, model) <= maxTokens {
+			return result
+		}
+	}
+	return ""
+}
+
+// SliceTokens returns the part of text covered by tokens[start:end], widened
+// if needed to avoid splitting a character. Indices are clamped to the token count.
+func SliceTokens(text string, start, end int, model string) string {
+	tokens, offsets := encodeWithOffsets(text, model)
+	start, end = clamp(start, 0, len(tokens)), clamp(end, 0, len(tokens))
+	if start >= end {
+		return ""
+	}
+	return text[offsets[charBoundaryBefore(text, offsets, start)]:offsets[charBoundaryAfter(text, offsets, end)]]
+}
+
+// TokenWindows splits text into consecutive windows of at most size tokens,
+// with each window repeating the last overlap tokens of the previous one.
+// This is meant for computing embeddings of long documents. Windows never split
+// characters, so a window can be a few tokens shorter (or, in the unlikely case
+// of a single character spanning more than size tokens, longer) than size.
+func TokenWindows(text string, size, overlap int, model string) []string {
+	if size <= 0 || overlap < 0 || overlap >= size {
+		panic(fmt.Errorf("invalid token window size %d with overlap %d", size, overlap))
+	}
+	tokens, offsets := encodeWithOffsets(text, model)
+	var result []string
+	for start := 0; start < len(tokens); {
+		end := charBoundaryBefore(text, offsets, clamp(start+size, 0, len(tokens)))
+		if end <= start {
+			end = charBoundaryAfter(text, offsets, start+1)
+		}
+		result = append(result, text[offsets[start]:offsets[end]])
+		if end == len(tokens) {
+			break
+		}
+		next := charBoundaryAfter(text, offsets, max(end-overlap, start+1))
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return result
+}
+
+// encodeWithOffsets returns the tokens of text along with their byte offsets
+// in it; offsets has an extra element equal to len(text).
+func encodeWithOffsets(text, model string) (tokens, offsets []int) {
+	spans := EncodeWithOffsets(text, model)
+	tokens = make([]int, len(spans))
+	offsets = make([]int, len(spans)+1)
+	for i, span := range spans {
+		tokens[i] = span.Token
+		offsets[i] = span.Start
+	}
+	offsets[len(spans)] = len(text)
+	return tokens, offsets
+}
+
+// charBoundaryBefore returns the largest i <= n such that token i starts a character.
+func charBoundaryBefore(text string, offsets []int, n int) int {
+	for n > 0 && !isCharBoundary(text, offsets[n]) {
+		n--
+	}
+	return n
+}
+
+// charBoundaryAfter returns the smallest i >= n such that token i starts a character.
+func charBoundaryAfter(text string, offsets []int, n int) int {
+	last := len(offsets) - 1
+	for n < last && !isCharBoundary(text, offsets[n]) {
+		n++
+	}
+	return n
+}
+
+func isCharBoundary(text string, offset int) bool {
+	return offset == len(text) || utf8.RuneStart(text[offset])
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	} else if v > hi {
+		return hi
+	} else {
+		return v
+	}
+}
@@ -0,0 +1,88 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateTokens(t *testing.T) {
+	const model = ModelDefaultChat
+	tests := []struct {
+		input     string
+		maxTokens int
+		ellipsis  string
+		expected  string
+		fromStart string
+	}{
+		{"Hello, world.", 10, "…", "Hello, world.", "Hello, world."},
+		{"Hello, world.", 4, "…", "Hello, world.", "Hello, world."},
+		{"Hello, world.", 3, "", "Hello, world", ", world."},
+		{"Hello, world.", 3, "...", "Hello,...", "... world."},
+		{"Hello, world.", 0, "", "", ""},
+		{"Hello, world.", 0, "...", "", ""},
+		{"Emojis: 🤚🏾🤚🏾", 5, "", "Emojis:", "🏾"},
+	}
+	for _, test := range tests {
+		if actual := TruncateTokens(test.input, test.maxTokens, test.ellipsis, model); actual != test.expected {
+			t.Errorf("** TruncateTokens(%q, %d, %q) = %q, wanted %q", test.input, test.maxTokens, test.ellipsis, actual, test.expected)
+		}
+		if actual := TruncateTokensFromStart(test.input, test.maxTokens, test.ellipsis, model); actual != test.fromStart {
+			t.Errorf("** TruncateTokensFromStart(%q, %d, %q) = %q, wanted %q", test.input, test.maxTokens, test.ellipsis, actual, test.fromStart)
+		}
+	}
+}
+
+func TestSliceTokens(t *testing.T) {
+	const model = ModelDefaultChat
+	tests := []struct {
+		input      string
+		start, end int
+		expected   string
+	}{
+		{"Hello, world.", 0, 4, "Hello, world."},
+		{"Hello, world.", 1, 3, ", world"},
+		{"Hello, world.", 3, 100, "."},
+		{"Hello, world.", 3, 1, ""},
+		{"Emojis: 🤚🏾", 6, 7, " 🤚"},
+	}
+	for _, test := range tests {
+		if actual := SliceTokens(test.input, test.start, test.end, model); actual != test.expected {
+			t.Errorf("** SliceTokens(%q, %d, %d) = %q, wanted %q", test.input, test.start, test.end, actual, test.expected)
+		}
+	}
+}
+
+func TestTokenWindows(t *testing.T) {
+	const model = ModelDefaultChat
+	input := strings.Repeat("Sequences of characters commonly found next to each other 🤚🏾 may be grouped together. ", 20)
+	windows := checkTokenWindows(t, input, 50, 10, model)
+	if len(windows) < 2 {
+		t.Fatalf("** TokenWindows returned %d windows, wanted several", len(windows))
+	}
+
+	// the first window ends right before a multi-token character, so it's shorter than the overlap
+	for _, input := range []string{"a🦄", "a𝔘𝔫𝔦", "a🧑‍🚀"} {
+		checkTokenWindows(t, input, 3, 2, ModelChatGPT4)
+	}
+}
+
+func checkTokenWindows(t *testing.T, input string, size, overlap int, model string) []string {
+	t.Helper()
+	windows := TokenWindows(input, size, overlap, model)
+	for i, w := range windows {
+		if !utf8.ValidString(w) {
+			t.Errorf("** %q: window %d is not valid UTF-8: %q", input, i, w)
+		}
+		if !strings.Contains(input, w) {
+			t.Errorf("** %q: window %d is not a substring of the input: %q", input, i, w)
+		}
+		if n := TokenCount(w, model); n > size && utf8.RuneCountInString(w) > 1 {
+			t.Errorf("** %q: window %d has %d tokens, wanted at most %d", input, i, n, size)
+		}
+	}
+	if len(windows) == 0 || !strings.HasPrefix(input, windows[0]) || !strings.HasSuffix(input, windows[len(windows)-1]) {
+		t.Errorf("** TokenWindows(%q) = %q does not cover the input", input, windows)
+	}
+	return windows
+}
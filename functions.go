@@ -0,0 +1,144 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// functionDef is the subset of a function definition that affects the prompt.
+type functionDef struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parameters  *jsonSchema `json:"parameters"`
+}
+
+type toolDef struct {
+	Type     string       `json:"type"`
+	Function *functionDef `json:"function"`
+}
+
+type jsonSchema struct {
+	Type        any                    `json:"type"`
+	Description string                 `json:"description"`
+	Enum        []any                  `json:"enum"`
+	Properties  map[string]*jsonSchema `json:"properties"`
+	Required    []string               `json:"required"`
+	Items       *jsonSchema            `json:"items"`
+}
+
+// functionDefinitions collects function definitions from both opt.Functions and opt.Tools.
+// Definitions that cannot be interpreted are skipped; the API would reject them anyway.
+func functionDefinitions(opt Options) []*functionDef {
+	var result []*functionDef
+	for _, f := range opt.Functions {
+		var def functionDef
+		if json.Unmarshal(saneMarshal(f), &def) == nil && def.Name != "" {
+			result = append(result, &def)
+		}
+	}
+	for _, t := range opt.Tools {
+		var tool toolDef
+		if json.Unmarshal(saneMarshal(t), &tool) == nil && tool.Type == "function" && tool.Function != nil && tool.Function.Name != "" {
+			result = append(result, tool.Function)
+		}
+	}
+	return result
+}
+
+// formatFunctionDefinitions renders function definitions the way OpenAI injects them
+// into the system prompt, as a TypeScript-like namespace:
+//
+//	namespace functions {
+//
+//	// Get the weather
+//	type get_weather = (_: {
+//	// City name
+//	city: string,
+//	unit?: "c" | "f",
+//	}) => any;
+//
+//	} // namespace functions
+func formatFunctionDefinitions(defs []*functionDef) string {
+	var buf strings.Builder
+	buf.WriteString("namespace functions {\n\n")
+	for _, def := range defs {
+		if def.Description != "" {
+			fmt.Fprintf(&buf, "// %s\n", def.Description)
+		}
+		if def.Parameters != nil && len(def.Parameters.Properties) > 0 {
+			fmt.Fprintf(&buf, "type %s = (_: {\n", def.Name)
+			formatSchemaProperties(&buf, def.Parameters, 0)
+			buf.WriteString("}) => any;\n\n")
+		} else {
+			fmt.Fprintf(&buf, "type %s = () => any;\n\n", def.Name)
+		}
+	}
+	buf.WriteString("} // namespace functions")
+	return buf.String()
+}
+
+func formatSchemaProperties(buf *strings.Builder, schema *jsonSchema, indent int) {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	prefix := strings.Repeat(" ", indent)
+	for _, name := range names {
+		prop := schema.Properties[name]
+		if prop == nil {
+			continue
+		}
+		if prop.Description != "" && indent < 2 {
+			fmt.Fprintf(buf, "%s// %s\n", prefix, prop.Description)
+		}
+		optional := "?"
+		for _, r := range schema.Required {
+			if r == name {
+				optional = ""
+				break
+			}
+		}
+		fmt.Fprintf(buf, "%s%s%s: %s,\n", prefix, name, optional, formatSchemaType(prop, indent))
+	}
+}
+
+func formatSchemaType(schema *jsonSchema, indent int) string {
+	typ, _ := schema.Type.(string)
+	switch typ {
+	case "string", "number", "integer":
+		if len(schema.Enum) > 0 {
+			values := make([]string, 0, len(schema.Enum))
+			for _, v := range schema.Enum {
+				if s, ok := v.(string); ok {
+					values = append(values, `"`+s+`"`)
+				} else {
+					values = append(values, fmt.Sprint(v))
+				}
+			}
+			return strings.Join(values, " | ")
+		}
+		if typ == "integer" {
+			return "number"
+		}
+		return typ
+	case "boolean", "null":
+		return typ
+	case "object":
+		var buf strings.Builder
+		buf.WriteString("{\n")
+		formatSchemaProperties(&buf, schema, indent+2)
+		buf.WriteString("}")
+		return buf.String()
+	case "array":
+		if schema.Items != nil {
+			return formatSchemaType(schema.Items, indent) + "[]"
+		}
+		return "any[]"
+	default:
+		return ""
+	}
+}
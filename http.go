@@ -7,8 +7,13 @@ import (
 	"fmt"
 	"io"
 	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -29,6 +34,10 @@ type Error struct {
 	RawResponseBody   []byte
 	PrintResponseBody bool
 	Cause             error
+
+	// RetryAfter is the delay requested by the Retry-After header of the response,
+	// typically with HTTP 429 or 503 errors; 0 if there was none.
+	RetryAfter time.Duration
 }
 
 func (e *Error) Error() string {
@@ -68,6 +77,9 @@ func (e *Error) Unwrap() error {
 
 type streamSync = func(data []byte) error
 
+// eventSync is a streamSync that also receives SSE event IDs and names.
+type eventSync = func(id, event string, data []byte) error
+
 func saneMarshal(v any) []byte {
 	var buf bytes.Buffer
 	e := json.NewEncoder(&buf)
@@ -77,19 +89,49 @@ func saneMarshal(v any) []byte {
 }
 
 func post(ctx context.Context, callID, endpoint string, client *http.Client, creds Credentials, input any, outputPtr any) error {
-	inputRaw := saneMarshal(input)
-	r := must(http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(inputRaw)))
+	return call(ctx, callID, http.MethodPost, endpoint, client, creds, input, outputPtr)
+}
+
+func get(ctx context.Context, callID, endpoint string, client *http.Client, creds Credentials, outputPtr any) error {
+	return call(ctx, callID, http.MethodGet, endpoint, client, creds, nil, outputPtr)
+}
+
+func del(ctx context.Context, callID, endpoint string, client *http.Client, creds Credentials, outputPtr any) error {
+	return call(ctx, callID, http.MethodDelete, endpoint, client, creds, nil, outputPtr)
+}
+
+// call performs an API request and decodes the response into outputPtr.
+//
+// Input is sent as JSON, unless it's nil or a *rawBody. Output is passed to outputPtr
+// if it's a streamSync or an eventSync, copied into it if it's an io.Writer, and unmarshaled otherwise.
+func call(ctx context.Context, callID, method, endpoint string, client *http.Client, creds Credentials, input any, outputPtr any) error {
+	var inputRaw []byte
+	var body io.Reader
+	var ctype string
+	switch input := input.(type) {
+	case nil:
+		break
+	case *rawBody:
+		body, ctype = input.Reader, input.ContentType
+	default:
+		inputRaw = saneMarshal(input)
+		body, ctype = bytes.NewReader(inputRaw), "application/json"
+	}
+	r := must(http.NewRequestWithContext(ctx, method, endpoint, body))
 
 	h := r.Header
 	h.Set("Authorization", "Bearer "+creds.APIKey)
-	h.Set("Content-Type", "application/json")
+	if ctype != "" {
+		h.Set("Content-Type", ctype)
+	}
 	if creds.OrganizationID != "" {
 		h.Set("OpenAI-Organization", creds.OrganizationID)
 	}
+	if beta := betaFeature(endpoint); beta != "" {
+		h.Set("OpenAI-Beta", beta)
+	}
 
-	// log.Printf("%s: %s", callID, curl(r.Method, r.URL.String(), r.Header, inputRaw))
-
-	resp, err := client.Do(r)
+	resp, err := roundTrip(&Call{ID: callID, Request: r, Body: inputRaw}, client)
 	if err != nil {
 		return &Error{
 			CallID:    callID,
@@ -102,7 +144,17 @@ func post(ctx context.Context, callID, endpoint string, client *http.Client, cre
 	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
 		ctype, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
 
-		if f, ok := outputPtr.(streamSync); ok {
+		var f eventSync
+		switch sync := outputPtr.(type) {
+		case streamSync:
+			f = func(id, event string, data []byte) error {
+				return sync(data)
+			}
+		case eventSync:
+			f = sync
+		}
+
+		if f != nil {
 			if ctype != eventStreamContentType {
 				outputRaw, err := io.ReadAll(resp.Body)
 				if err != nil {
@@ -124,7 +176,7 @@ func post(ctx context.Context, callID, endpoint string, client *http.Client, cre
 				if bytes.Equal(data, streamEndMarker) {
 					return errCloseEventStream
 				}
-				return f(data)
+				return f(id, event, data)
 			}, nil)
 			if err != nil {
 				return &Error{
@@ -135,6 +187,17 @@ func post(ctx context.Context, callID, endpoint string, client *http.Client, cre
 					Cause:      err,
 				}
 			}
+		} else if w, ok := outputPtr.(io.Writer); ok {
+			_, err := io.Copy(w, resp.Body)
+			if err != nil {
+				return &Error{
+					CallID:     callID,
+					IsNetwork:  true,
+					StatusCode: resp.StatusCode,
+					Message:    "error downloading body",
+					Cause:      err,
+				}
+			}
 		} else {
 			outputRaw, err := io.ReadAll(resp.Body)
 			if err != nil {
@@ -174,6 +237,7 @@ func post(ctx context.Context, callID, endpoint string, client *http.Client, cre
 			StatusCode:        resp.StatusCode,
 			RawResponseBody:   outputRaw,
 			PrintResponseBody: true,
+			RetryAfter:        parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
 		}
 		var errResp errorResponse
 		err = json.Unmarshal(outputRaw, &errResp)
@@ -193,6 +257,108 @@ func post(ctx context.Context, callID, endpoint string, client *http.Client, cre
 	}
 }
 
+// parseRetryAfter parses a Retry-After header value, which is either a number
+// of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if sec, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// betaFeature returns the OpenAI-Beta header value required by the endpoint, if any.
+func betaFeature(endpoint string) string {
+	if strings.HasPrefix(endpoint, "https://api.openai.com/v1/assistants") || strings.HasPrefix(endpoint, "https://api.openai.com/v1/threads") {
+		return "assistants=v2"
+	}
+	return ""
+}
+
+// ListOptions control pagination of list calls. The zero value returns the first page.
+type ListOptions struct {
+	// After is the ID of the last item of the previous page.
+	After string
+
+	// Limit is the number of items to return; 0 uses the API default.
+	Limit int
+}
+
+func (opt ListOptions) query(endpoint string) string {
+	if opt.After != "" {
+		endpoint = appendQuery(endpoint, "after", opt.After)
+	}
+	if opt.Limit > 0 {
+		endpoint = appendQuery(endpoint, "limit", strconv.Itoa(opt.Limit))
+	}
+	return endpoint
+}
+
+func appendQuery(endpoint, key, value string) string {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return endpoint + sep + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
+
+type listResponse[T any] struct {
+	Data    []T  `json:"data"`
+	HasMore bool `json:"has_more"`
+}
+
+// rawBody is a call input that is sent as is, instead of being marshaled into JSON.
+type rawBody struct {
+	ContentType string
+	Reader      io.Reader
+}
+
+type formField struct {
+	Name  string
+	Value string
+}
+
+type formFile struct {
+	Field    string
+	Filename string
+	Reader   io.Reader
+}
+
+// multipartBody streams a multipart/form-data body with the given fields and files.
+// Files are copied as the request is being sent, so they are never buffered in memory.
+func multipartBody(fields []formField, files []formFile) *rawBody {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		// if the request fails, the transport closes pr, and writes fail with io.ErrClosedPipe
+		pw.CloseWithError(writeMultipart(mw, fields, files))
+	}()
+	return &rawBody{mw.FormDataContentType(), pr}
+}
+
+func writeMultipart(mw *multipart.Writer, fields []formField, files []formFile) error {
+	for _, f := range fields {
+		if err := mw.WriteField(f.Name, f.Value); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		part, err := mw.CreateFormFile(f.Field, f.Filename)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, f.Reader); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
 type errorResponse struct {
 	Error *struct {
 		Message any `json:"message"`
@@ -223,22 +389,23 @@ func curl(method, path string, headers http.Header, body []byte) string {
 	} else {
 		body = nil
 	}
-	for k, vv := range headers {
-		for _, v := range vv {
-			buf.WriteString(" -H '")
-			buf.WriteString(k)
-			buf.WriteString(": ")
-			buf.WriteString(v)
-			buf.WriteString("'")
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range headers[k] {
+			buf.WriteString(" -H ")
+			buf.WriteString(shellQuote(k + ": " + v))
 		}
 	}
 	if body != nil {
 		buf.WriteString(" -d ")
 		buf.WriteString(shellQuote(string(body)))
 	}
-	buf.WriteString(" '")
-	buf.WriteString(path)
-	buf.WriteString("'")
+	buf.WriteString(" ")
+	buf.WriteString(shellQuote(path))
 	return buf.String()
 }
 
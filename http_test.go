@@ -0,0 +1,61 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client that sends all requests to handler instead of the real API.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *http.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	u := must(url.Parse(srv.URL))
+	return &http.Client{Transport: &redirectTransport{u}}
+}
+
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestCallError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"message": "Invalid job ID", "type": "invalid_request_error"}}`))
+	})
+	_, err := RetrieveFineTuningJob(context.Background(), "ftjob-123", client, Credentials{})
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != 400 || e.Type != "invalid_request_error" || e.Message != "Invalid job ID" {
+		t.Errorf("** err = %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"Tue, 02 Jan 2024 03:04:35 GMT", 30 * time.Second},
+		{"Tue, 02 Jan 2024 03:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if a := parseRetryAfter(tt.value, now); a != tt.expected {
+			t.Errorf("** parseRetryAfter(%q) = %v, wanted %v", tt.value, a, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Image sizes. Each model supports only some of them.
+const (
+	ImageSize256       = "256x256"   // ModelDallE2
+	ImageSize512       = "512x512"   // ModelDallE2
+	ImageSize1024      = "1024x1024" // all models
+	ImageSize1792x1024 = "1792x1024" // ModelDallE3
+	ImageSize1024x1792 = "1024x1792" // ModelDallE3
+	ImageSize1536x1024 = "1536x1024" // ModelGPTImage1
+	ImageSize1024x1536 = "1024x1536" // ModelGPTImage1
+)
+
+// Image qualities. ModelDallE3 supports standard and hd, ModelGPTImage1 supports low, medium and high.
+const (
+	ImageQualityStandard = "standard"
+	ImageQualityHD       = "hd"
+	ImageQualityLow      = "low"
+	ImageQualityMedium   = "medium"
+	ImageQualityHigh     = "high"
+)
+
+// Image styles, only supported by ModelDallE3.
+const (
+	ImageStyleVivid   = "vivid"
+	ImageStyleNatural = "natural"
+)
+
+// ImageOptions control image generation, edits and variations. The zero value produces
+// a single 1024x1024 image with ModelDallE2, returned as a URL.
+type ImageOptions struct {
+	Model   string
+	N       int    // number of images, 0 means 1; ModelDallE3 only supports 1
+	Size    string // e.g. ImageSize1024
+	Quality string // e.g. ImageQualityHD
+	Style   string // e.g. ImageStyleVivid
+
+	// Base64 requests image bytes in the response instead of URLs valid for an hour.
+	// ModelGPTImage1 always returns bytes.
+	Base64 bool
+
+	// User is an optional ID of the end user to help OpenAI detect abuse.
+	User string
+}
+
+// Image is a generated image. Either URL or Data is set, depending on ImageOptions.Base64.
+type Image struct {
+	URL  string
+	Data []byte // usually PNG
+
+	// RevisedPrompt is the prompt ModelDallE3 has actually used.
+	RevisedPrompt string
+}
+
+// GenerateImage creates images from a text prompt.
+func GenerateImage(ctx context.Context, prompt string, opt ImageOptions, client *http.Client, creds Credentials) ([]*Image, error) {
+	const callID = "GenerateImage"
+	req := &imageRequest{
+		Model:   opt.Model,
+		Prompt:  prompt,
+		N:       opt.N,
+		Size:    opt.Size,
+		Quality: opt.Quality,
+		Style:   opt.Style,
+		User:    opt.User,
+	}
+	if opt.Base64 {
+		req.ResponseFormat = "b64_json"
+	}
+	var resp imageResponse
+	err := post(ctx, callID, "https://api.openai.com/v1/images/generations", client, creds, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.images(callID)
+}
+
+// EditImage modifies an image according to the prompt. The image is read from r and
+// must be a square PNG with ModelDallE2. The optional mask is a PNG of the same size whose
+// fully transparent areas indicate where the image should be edited; without a mask,
+// the transparent areas of the image itself are edited.
+func EditImage(ctx context.Context, r io.Reader, filename string, mask io.Reader, prompt string, opt ImageOptions, client *http.Client, creds Credentials) ([]*Image, error) {
+	const callID = "EditImage"
+	fields := append(opt.formFields(), formField{"prompt", prompt})
+	files := []formFile{{"image", filename, r}}
+	if mask != nil {
+		files = append(files, formFile{"mask", "mask.png", mask})
+	}
+	var resp imageResponse
+	err := post(ctx, callID, "https://api.openai.com/v1/images/edits", client, creds, multipartBody(fields, files), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.images(callID)
+}
+
+// CreateImageVariation generates variations of an image read from r, which must be
+// a square PNG. Only ModelDallE2 supports variations.
+func CreateImageVariation(ctx context.Context, r io.Reader, filename string, opt ImageOptions, client *http.Client, creds Credentials) ([]*Image, error) {
+	const callID = "CreateImageVariation"
+	files := []formFile{{"image", filename, r}}
+	var resp imageResponse
+	err := post(ctx, callID, "https://api.openai.com/v1/images/variations", client, creds, multipartBody(opt.formFields(), files), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.images(callID)
+}
+
+func (opt ImageOptions) formFields() []formField {
+	var fields []formField
+	if opt.Model != "" {
+		fields = append(fields, formField{"model", opt.Model})
+	}
+	if opt.N > 0 {
+		fields = append(fields, formField{"n", strconv.Itoa(opt.N)})
+	}
+	if opt.Size != "" {
+		fields = append(fields, formField{"size", opt.Size})
+	}
+	if opt.Quality != "" {
+		fields = append(fields, formField{"quality", opt.Quality})
+	}
+	if opt.Base64 {
+		fields = append(fields, formField{"response_format", "b64_json"})
+	}
+	if opt.User != "" {
+		fields = append(fields, formField{"user", opt.User})
+	}
+	return fields
+}
+
+type imageRequest struct {
+	Model          string `json:"model,omitempty"`
+	Prompt         string `json:"prompt"`
+	N              int    `json:"n,omitempty"`
+	Size           string `json:"size,omitempty"`
+	Quality        string `json:"quality,omitempty"`
+	Style          string `json:"style,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+type imageResponse struct {
+	Created int64 `json:"created"`
+	Data    []struct {
+		URL           string `json:"url"`
+		B64JSON       string `json:"b64_json"`
+		RevisedPrompt string `json:"revised_prompt"`
+	} `json:"data"`
+}
+
+func (resp *imageResponse) images(callID string) ([]*Image, error) {
+	if len(resp.Data) == 0 {
+		return nil, &Error{
+			CallID:  callID,
+			Message: "no results",
+		}
+	}
+	result := make([]*Image, 0, len(resp.Data))
+	for _, d := range resp.Data {
+		img := &Image{URL: d.URL, RevisedPrompt: d.RevisedPrompt}
+		if d.B64JSON != "" {
+			data, err := base64.StdEncoding.DecodeString(d.B64JSON)
+			if err != nil {
+				return nil, &Error{
+					CallID:  callID,
+					Message: "invalid base64 image data",
+					Cause:   err,
+				}
+			}
+			img.Data = data
+		}
+		result = append(result, img)
+	}
+	return result, nil
+}
+
+type imagePriceKey struct {
+	model, quality, size string
+}
+
+// imagePrices are per image. ModelGPTImage1 is actually billed per token, these are
+// the official per-image estimates.
+var imagePrices = map[imagePriceKey]Price{
+	{ModelDallE2, ImageQualityStandard, ImageSize256}:  1_600_000,
+	{ModelDallE2, ImageQualityStandard, ImageSize512}:  1_800_000,
+	{ModelDallE2, ImageQualityStandard, ImageSize1024}: 2_000_000,
+
+	{ModelDallE3, ImageQualityStandard, ImageSize1024}:      4_000_000,
+	{ModelDallE3, ImageQualityStandard, ImageSize1792x1024}: 8_000_000,
+	{ModelDallE3, ImageQualityStandard, ImageSize1024x1792}: 8_000_000,
+	{ModelDallE3, ImageQualityHD, ImageSize1024}:            8_000_000,
+	{ModelDallE3, ImageQualityHD, ImageSize1792x1024}:       12_000_000,
+	{ModelDallE3, ImageQualityHD, ImageSize1024x1792}:       12_000_000,
+
+	{ModelGPTImage1, ImageQualityLow, ImageSize1024}:         1_100_000,
+	{ModelGPTImage1, ImageQualityLow, ImageSize1536x1024}:    1_600_000,
+	{ModelGPTImage1, ImageQualityLow, ImageSize1024x1536}:    1_600_000,
+	{ModelGPTImage1, ImageQualityMedium, ImageSize1024}:      4_200_000,
+	{ModelGPTImage1, ImageQualityMedium, ImageSize1536x1024}: 6_300_000,
+	{ModelGPTImage1, ImageQualityMedium, ImageSize1024x1536}: 6_300_000,
+	{ModelGPTImage1, ImageQualityHigh, ImageSize1024}:        16_700_000,
+	{ModelGPTImage1, ImageQualityHigh, ImageSize1536x1024}:   25_000_000,
+	{ModelGPTImage1, ImageQualityHigh, ImageSize1024x1536}:   25_000_000,
+}
+
+// ImageCost estimates the cost of generating images with the given options.
+// Panics for unknown combinations; use ImageCostE to handle them gracefully.
+func ImageCost(opt ImageOptions) Price {
+	return must(ImageCostE(opt))
+}
+
+// ImageCostE estimates the cost of generating images with the given options, applying
+// the same defaults as the API. The automatic quality of ModelGPTImage1 is priced as high.
+func ImageCostE(opt ImageOptions) (Price, error) {
+	key := imagePriceKey{opt.Model, opt.Quality, opt.Size}
+	if key.model == "" {
+		key.model = ModelDallE2
+	}
+	if key.size == "" || key.size == "auto" {
+		key.size = ImageSize1024
+	}
+	switch key.model {
+	case ModelDallE2:
+		key.quality = ImageQualityStandard
+	case ModelDallE3:
+		if key.quality == "" {
+			key.quality = ImageQualityStandard
+		}
+	case ModelGPTImage1:
+		if key.quality == "" || key.quality == "auto" {
+			key.quality = ImageQualityHigh
+		}
+	}
+	price, ok := imagePrices[key]
+	if !ok {
+		return 0, fmt.Errorf("no pricing known for %s image of size %s and %s quality", key.model, key.size, key.quality)
+	}
+	n := opt.N
+	if n <= 0 {
+		n = 1
+	}
+	return price * Price(n), nil
+}
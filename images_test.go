@@ -0,0 +1,88 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestImages(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/images/generations":
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"model":"dall-e-3","prompt":"a cat","size":"1792x1024","quality":"hd","style":"natural"}`+"\n" {
+				http.Error(w, "unexpected body "+string(body), http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"created": 1, "data": [{"url": "https://example.com/cat.png", "revised_prompt": "a fluffy cat"}]}`))
+		case "/v1/images/edits":
+			if err := r.ParseMultipartForm(1024); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			img, _, err1 := r.FormFile("image")
+			mask, _, err2 := r.FormFile("mask")
+			if err1 != nil || err2 != nil || r.FormValue("prompt") != "add a hat" || r.FormValue("n") != "2" || r.FormValue("response_format") != "b64_json" {
+				http.Error(w, "unexpected edit", http.StatusBadRequest)
+				return
+			}
+			imgData, _ := io.ReadAll(img)
+			maskData, _ := io.ReadAll(mask)
+			if string(imgData) != "IMG" || string(maskData) != "MASK" {
+				http.Error(w, "unexpected files", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"created": 1, "data": [{"b64_json": "aGVsbG8="}, {"b64_json": "d29ybGQ="}]}`))
+		case "/v1/images/variations":
+			if err := r.ParseMultipartForm(1024); err != nil || r.MultipartForm.File["mask"] != nil {
+				http.Error(w, "unexpected variation", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"created": 1, "data": [{"b64_json": "!!!"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	images, err := GenerateImage(ctx, "a cat", ImageOptions{Model: ModelDallE3, Size: ImageSize1792x1024, Quality: ImageQualityHD, Style: ImageStyleNatural}, client, Credentials{})
+	if err != nil || len(images) != 1 || images[0].URL != "https://example.com/cat.png" || images[0].RevisedPrompt != "a fluffy cat" {
+		t.Errorf("** GenerateImage = %v, %v", images, err)
+	}
+
+	images, err = EditImage(ctx, strings.NewReader("IMG"), "cat.png", strings.NewReader("MASK"), "add a hat", ImageOptions{N: 2, Base64: true}, client, Credentials{})
+	if err != nil || len(images) != 2 || string(images[0].Data) != "hello" || string(images[1].Data) != "world" {
+		t.Errorf("** EditImage = %v, %v", images, err)
+	}
+
+	_, err = CreateImageVariation(ctx, strings.NewReader("IMG"), "cat.png", ImageOptions{Base64: true}, client, Credentials{})
+	if err == nil || !strings.Contains(err.Error(), "invalid base64") {
+		t.Errorf("** CreateImageVariation with invalid data: %v", err)
+	}
+}
+
+func TestImageCost(t *testing.T) {
+	tests := []struct {
+		opt      ImageOptions
+		expected string
+	}{
+		{ImageOptions{}, "$0.02"},
+		{ImageOptions{Model: ModelDallE2, Size: ImageSize256, Quality: ImageQualityHD, N: 10}, "$0.16"},
+		{ImageOptions{Model: ModelDallE3}, "$0.04"},
+		{ImageOptions{Model: ModelDallE3, Size: ImageSize1024x1792, Quality: ImageQualityHD}, "$0.12"},
+		{ImageOptions{Model: ModelGPTImage1, Quality: ImageQualityLow, N: 2}, "$0.02"},
+		{ImageOptions{Model: ModelGPTImage1, Size: ImageSize1536x1024}, "$0.25"},
+	}
+	for _, tt := range tests {
+		if a := ImageCost(tt.opt).String(); a != tt.expected {
+			t.Errorf("** ImageCost(%+v) = %s, wanted %s", tt.opt, a, tt.expected)
+		}
+	}
+
+	if _, err := ImageCostE(ImageOptions{Model: ModelDallE3, Size: ImageSize256}); err == nil {
+		t.Errorf("** ImageCostE of unsupported size succeeded")
+	}
+}
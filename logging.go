@@ -0,0 +1,265 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LogOptions configure LogMiddleware.
+type LogOptions struct {
+	// Level is the level of successful calls; defaults to slog.LevelInfo.
+	Level slog.Leveler
+
+	// ErrorLevel is the level of failed calls; defaults to slog.LevelError.
+	ErrorLevel slog.Leveler
+
+	// LogContent adds request and response bodies to log records. These include
+	// prompts and completions, so it's off by default; see also Redact.
+	LogContent bool
+
+	// Redact, if set, is applied to request and response bodies before logging them,
+	// e.g. to mask personal data.
+	Redact func(content string) string
+}
+
+// LogMiddleware logs every API call to logger, once its response has been read,
+// with the call ID, model, latency, status code, request ID and, when the response
+// reports them, token usage and cost. Attach it via WithMiddleware:
+//
+//	ctx = openai.WithMiddleware(ctx, openai.LogMiddleware(slog.Default(), openai.LogOptions{}))
+//
+// Note that StreamChat only receives usage when called under a CostTracker.
+func LogMiddleware(logger *slog.Logger, opt LogOptions) Middleware {
+	if opt.Level == nil {
+		opt.Level = slog.LevelInfo
+	}
+	if opt.ErrorLevel == nil {
+		opt.ErrorLevel = slog.LevelError
+	}
+	return func(call *Call, next RoundTrip) (*http.Response, error) {
+		ctx := call.Request.Context()
+		if !logger.Enabled(ctx, opt.Level.Level()) && !logger.Enabled(ctx, opt.ErrorLevel.Level()) {
+			return next(call)
+		}
+		cl := &callLog{ctx: ctx, logger: logger, opt: &opt, call: call, start: time.Now()}
+		resp, err := next(call)
+		if err != nil {
+			cl.log(nil, nil, err)
+			return resp, err
+		}
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			// the body is the connection itself, e.g. a WebSocket
+			cl.log(resp, nil, nil)
+			return resp, nil
+		}
+		ctype, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		resp.Body = &loggedBody{
+			body:      resp.Body,
+			cl:        cl,
+			resp:      resp,
+			capture:   ctype == "application/json" || ctype == eventStreamContentType,
+			streaming: ctype == eventStreamContentType,
+		}
+		return resp, nil
+	}
+}
+
+type callLog struct {
+	ctx    context.Context // of the request; resp.Request may be nil if middleware made up resp
+	logger *slog.Logger
+	opt    *LogOptions
+	call   *Call
+	start  time.Time
+}
+
+// log records a call that failed with err, or completed with resp and its (captured) body.
+func (cl *callLog) log(resp *http.Response, body *loggedBody, err error) {
+	attrs := make([]slog.Attr, 0, 12)
+	attrs = append(attrs, slog.String("call", cl.call.ID))
+
+	var reqInfo loggedResponse
+	if cl.call.Body != nil {
+		json.Unmarshal(cl.call.Body, &reqInfo)
+	}
+	var info loggedResponse
+	if body != nil && body.capture {
+		info = body.info()
+	}
+	model := reqInfo.Model
+	if model == "" {
+		model = info.Model
+	}
+	if model != "" {
+		attrs = append(attrs, slog.String("model", model))
+	}
+
+	attrs = append(attrs, slog.Duration("latency", time.Since(cl.start)))
+	level := cl.opt.Level.Level()
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if id := resp.Header.Get("X-Request-Id"); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+		if resp.StatusCode >= 400 {
+			level = cl.opt.ErrorLevel.Level()
+			if err == nil && body != nil {
+				var errResp errorResponse
+				if json.Unmarshal(body.buf.Bytes(), &errResp) == nil && errResp.Error != nil {
+					if s, ok := errResp.Error.Message.(string); ok {
+						attrs = append(attrs, slog.String("error", s))
+					}
+				}
+			}
+		}
+	}
+	if err != nil {
+		level = cl.opt.ErrorLevel.Level()
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	if usage := info.usage(); usage != nil {
+		attrs = append(attrs,
+			slog.Int("prompt_tokens", usage.PromptTokens),
+			slog.Int("completion_tokens", usage.CompletionTokens),
+			slog.Int("total_tokens", usage.TotalTokens))
+		if cost, err := UsageCost(*usage, model); err == nil {
+			attrs = append(attrs, slog.Float64("cost_usd", float64(cost.Total())/100_000_000))
+		}
+	}
+
+	if cl.opt.LogContent {
+		if cl.call.Body != nil {
+			attrs = append(attrs, slog.String("request", cl.redact(string(bytes.TrimSpace(cl.call.Body)))))
+		}
+		if body != nil && body.capture {
+			attrs = append(attrs, slog.String("response", cl.redact(body.content())))
+		}
+	}
+	cl.logger.LogAttrs(cl.ctx, level, "openai call", attrs...)
+}
+
+func (cl *callLog) redact(content string) string {
+	if cl.opt.Redact != nil {
+		return cl.opt.Redact(content)
+	}
+	return content
+}
+
+// loggedResponse picks the model and usage out of responses and streaming chunks.
+type loggedResponse struct {
+	Model    string          `json:"model"`
+	Usage    json.RawMessage `json:"usage"`
+	Response *loggedResponse `json:"response"` // in Responses API events
+}
+
+func (r *loggedResponse) usage() *Usage {
+	if r.Response != nil {
+		if u := r.Response.usage(); u != nil {
+			return u
+		}
+	}
+	if len(r.Usage) == 0 || r.Usage[0] != '{' {
+		return nil
+	}
+	var usage Usage
+	json.Unmarshal(r.Usage, &usage)
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		// Responses and Realtime API usage uses different names
+		var ru ResponseUsage
+		json.Unmarshal(r.Usage, &ru)
+		usage = ru.ChatUsage()
+	}
+	return &usage
+}
+
+// loggedBody passes the response body through and logs the call once the body
+// has been read to the end or closed.
+type loggedBody struct {
+	body      io.ReadCloser
+	cl        *callLog
+	resp      *http.Response
+	capture   bool
+	streaming bool
+	buf       bytes.Buffer
+	done      bool
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if b.capture {
+		b.buf.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.finish(nil)
+	} else if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	b.finish(nil)
+	return b.body.Close()
+}
+
+func (b *loggedBody) finish(err error) {
+	if b.done {
+		return
+	}
+	b.done = true
+	b.cl.log(b.resp, b, err)
+}
+
+// info returns the model and the usage reported by the response; for streams,
+// the last ones reported by any chunk.
+func (b *loggedBody) info() loggedResponse {
+	var result loggedResponse
+	if !b.streaming {
+		json.Unmarshal(b.buf.Bytes(), &result)
+		return result
+	}
+	b.events(func(data []byte) {
+		var chunk loggedResponse
+		if json.Unmarshal(data, &chunk) != nil {
+			return
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Response != nil && chunk.Response.Model != "" {
+			result.Model = chunk.Response.Model
+		}
+		if chunk.usage() != nil {
+			result.Usage, result.Response = chunk.Usage, chunk.Response
+		}
+	})
+	return result
+}
+
+// content returns the response body, or the data of all events for streams.
+func (b *loggedBody) content() string {
+	if !b.streaming {
+		return string(bytes.TrimSpace(b.buf.Bytes()))
+	}
+	var events []string
+	b.events(func(data []byte) {
+		events = append(events, string(data))
+	})
+	return strings.Join(events, "\n")
+}
+
+func (b *loggedBody) events(f func(data []byte)) {
+	parseEventStream(bytes.NewReader(b.buf.Bytes()), 1024*1024, func(id, event string, data []byte) error {
+		if !bytes.Equal(data, streamEndMarker) {
+			f(data)
+		}
+		return nil
+	}, nil)
+}
@@ -0,0 +1,174 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// captureLog returns a logger and a function that returns and clears the records logged so far.
+func captureLog(level slog.Level) (*slog.Logger, func() []map[string]any) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+	return logger, func() []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var rec map[string]any
+			ensure(json.Unmarshal([]byte(line), &rec))
+			delete(rec, "time")
+			delete(rec, "latency")
+			records = append(records, rec)
+		}
+		buf.Reset()
+		return records
+	}
+}
+
+func TestLogMiddleware(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_1")
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case req.Msgs[0].Content == "fail":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": {"message": "Slow down", "type": "requests"}}`))
+		case req.Stream:
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {\"model\": \"gpt-4o-mini-2024-07-18\", \"choices\": [{\"delta\": {\"role\": \"assistant\", \"content\": \"Hi\"}}]}\n\n" +
+				"data: {\"model\": \"gpt-4o-mini-2024-07-18\", \"choices\": [], \"usage\": {\"prompt_tokens\": 1000000, \"completion_tokens\": 1000000, \"total_tokens\": 2000000}}\n\n" +
+				"data: [DONE]\n\n"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"model": "gpt-4o-mini-2024-07-18", "choices": [{"message": {"role": "assistant", "content": "Hello, Bob"}}], "usage": {"prompt_tokens": 1000000, "completion_tokens": 1000000, "total_tokens": 2000000}}`))
+		}
+	})
+	opt := DefaultChatOptions()
+	opt.Model = ModelChatGPT4oMini
+	logger, records := captureLog(slog.LevelInfo)
+
+	ctx := WithMiddleware(context.Background(), LogMiddleware(logger, LogOptions{}))
+	_, _, err := Chat(ctx, []Msg{UserMsg("I'm Bob")}, opt, client, Credentials{})
+	if err != nil {
+		t.Fatalf("** Chat: %v", err)
+	}
+	expected := map[string]any{
+		"level": "INFO", "msg": "openai call", "call": "Chat", "model": "gpt-4o-mini", "status": 200.0, "request_id": "req_1",
+		"prompt_tokens": 1000000.0, "completion_tokens": 1000000.0, "total_tokens": 2000000.0, "cost_usd": 0.75,
+	}
+	if recs := records(); len(recs) != 1 || !equalJSON(recs[0], expected) {
+		t.Errorf("** Chat logged %v, wanted %v", recs, expected)
+	}
+
+	// usage comes from the final chunk, which is requested under a CostTracker
+	ctx = WithCostTracker(ctx, &CostTracker{}, "")
+	_, err = StreamChat(ctx, []Msg{UserMsg("I'm Bob")}, opt, client, Credentials{}, func(msg *Msg, delta string) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("** StreamChat: %v", err)
+	}
+	expected["call"] = "StreamChat"
+	if recs := records(); len(recs) != 1 || !equalJSON(recs[0], expected) {
+		t.Errorf("** StreamChat logged %v, wanted %v", recs, expected)
+	}
+
+	_, _, err = Chat(ctx, []Msg{UserMsg("fail")}, opt, client, Credentials{})
+	if err == nil {
+		t.Fatalf("** Chat succeeded")
+	}
+	expected = map[string]any{
+		"level": "ERROR", "msg": "openai call", "call": "Chat", "model": "gpt-4o-mini", "status": 429.0, "request_id": "req_1", "error": "Slow down",
+	}
+	if recs := records(); len(recs) != 1 || !equalJSON(recs[0], expected) {
+		t.Errorf("** failed Chat logged %v, wanted %v", recs, expected)
+	}
+}
+
+func TestLogMiddlewareContent(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\": [{\"delta\": {\"role\": \"assistant\", \"content\": \"Hello, Bob\"}}]}\n\n" +
+			"data: [DONE]\n\n"))
+	})
+	logger, records := captureLog(slog.LevelDebug)
+	redact := func(s string) string {
+		return strings.ReplaceAll(s, "Bob", "***")
+	}
+	ctx := WithMiddleware(context.Background(), LogMiddleware(logger, LogOptions{Level: slog.LevelDebug, LogContent: true, Redact: redact}))
+	_, err := StreamChat(ctx, []Msg{UserMsg("I'm Bob")}, DefaultChatOptions(), client, Credentials{}, func(msg *Msg, delta string) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("** StreamChat: %v", err)
+	}
+	recs := records()
+	if len(recs) != 1 || recs[0]["level"] != "DEBUG" {
+		t.Fatalf("** logged %v", recs)
+	}
+	if req, _ := recs[0]["request"].(string); !strings.Contains(req, `"content":"I'm ***"`) {
+		t.Errorf("** request = %q", req)
+	}
+	if resp, _ := recs[0]["response"].(string); resp != `{"choices": [{"delta": {"role": "assistant", "content": "Hello, ***"}}]}` {
+		t.Errorf("** response = %q", resp)
+	}
+}
+
+func TestLogMiddlewareNetworkError(t *testing.T) {
+	logger, records := captureLog(slog.LevelInfo)
+	offline := func(call *Call, next RoundTrip) (*http.Response, error) {
+		return nil, errors.New("offline")
+	}
+	ctx := WithMiddleware(context.Background(), LogMiddleware(logger, LogOptions{ErrorLevel: slog.LevelWarn}), offline)
+	_, _, err := ComputeEmbedding(ctx, "Hi", http.DefaultClient, Credentials{})
+	if err == nil {
+		t.Fatalf("** ComputeEmbedding succeeded")
+	}
+	expected := map[string]any{"level": "WARN", "msg": "openai call", "call": "ComputeEmbedding", "model": ModelEmbeddingAda002, "error": "offline"}
+	if recs := records(); len(recs) != 1 || !equalJSON(recs[0], expected) {
+		t.Errorf("** logged %v, wanted %v", recs, expected)
+	}
+
+	// disabled levels skip logging altogether
+	logger, records = captureLog(slog.LevelError)
+	ctx = WithMiddleware(context.Background(), LogMiddleware(logger, LogOptions{ErrorLevel: slog.LevelWarn}), offline)
+	ComputeEmbedding(ctx, "Hi", http.DefaultClient, Credentials{})
+	if recs := records(); len(recs) != 0 {
+		t.Errorf("** logged %v", recs)
+	}
+}
+
+func TestLogMiddlewareCannedResponse(t *testing.T) {
+	logger, records := captureLog(slog.LevelInfo)
+	// responses made up by middleware have no Request
+	throttle := func(call *Call, next RoundTrip) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"error": {"message": "Throttled locally", "type": "requests"}}`)),
+		}, nil
+	}
+	ctx := WithMiddleware(context.Background(), LogMiddleware(logger, LogOptions{}), throttle)
+	_, _, err := ComputeEmbedding(ctx, "Hi", http.DefaultClient, Credentials{})
+	if err == nil {
+		t.Fatalf("** ComputeEmbedding succeeded")
+	}
+	expected := map[string]any{"level": "ERROR", "msg": "openai call", "call": "ComputeEmbedding", "model": ModelEmbeddingAda002, "status": 429.0, "error": "Throttled locally"}
+	if recs := records(); len(recs) != 1 || !equalJSON(recs[0], expected) {
+		t.Errorf("** logged %v, wanted %v", recs, expected)
+	}
+}
+
+func equalJSON(a, b map[string]any) bool {
+	return string(must(json.Marshal(a))) == string(must(json.Marshal(b)))
+}
@@ -0,0 +1,84 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+)
+
+// Call is an API request passing through Middleware.
+type Call struct {
+	// ID names the function making the call, e.g. "Chat"; it's the same as Error.CallID.
+	ID string
+
+	// Request is the outgoing request; its context is the one passed to the API function.
+	Request *http.Request
+
+	// Body is the JSON request body, or nil if there's no body or it's streamed
+	// (like file uploads). Request.Body must not be read by middleware.
+	Body []byte
+}
+
+// RoundTrip sends a Call and returns the response, see Middleware.
+type RoundTrip = func(call *Call) (*http.Response, error)
+
+// Middleware wraps API calls for logging, metrics, tracing and such.
+//
+// Middleware normally calls next, inspects the result and returns it. It can also
+// modify the request or the response, wrap the response body to observe it being read
+// (e.g. to time a streaming response to the end), or return its own response or error
+// without calling next at all (the request body is then closed automatically).
+//
+// The returned response is then handled as if it came from http.Client.Do, so the time
+// taken by next does not include reading the body, and errors that happen while
+// handling the response (like API errors and malformed JSON) are not seen by middleware.
+type Middleware = func(call *Call, next RoundTrip) (*http.Response, error)
+
+type middlewareKey struct{}
+
+// WithMiddleware returns a context that runs API calls made with it through the given
+// middleware, in order. Middleware already attached to ctx runs first (i.e. is outermost).
+func WithMiddleware(ctx context.Context, mw ...Middleware) context.Context {
+	prev, _ := ctx.Value(middlewareKey{}).([]Middleware)
+	chain := make([]Middleware, 0, len(prev)+len(mw))
+	chain = append(chain, prev...)
+	chain = append(chain, mw...)
+	return context.WithValue(ctx, middlewareKey{}, chain)
+}
+
+// roundTrip sends the call via client through the middleware attached to the request context.
+// If middleware doesn't send the call, roundTrip closes the request body, so that streamed
+// bodies (see multipartBody) are released.
+func roundTrip(call *Call, client *http.Client) (*http.Response, error) {
+	body := call.Request.Body
+	var sent bool
+	next := func(call *Call) (*http.Response, error) {
+		sent = true
+		return client.Do(call.Request)
+	}
+	chain, _ := call.Request.Context().Value(middlewareKey{}).([]Middleware)
+	for i := len(chain) - 1; i >= 0; i-- {
+		mw, inner := chain[i], next
+		next = func(call *Call) (*http.Response, error) {
+			return mw(call, inner)
+		}
+	}
+	resp, err := next(call)
+	if !sent && body != nil {
+		body.Close()
+	}
+	return resp, err
+}
+
+// CurlMiddleware logs each call as an equivalent curl command via logf (e.g. log.Printf)
+// before sending it. The API key is redacted, and streamed bodies are omitted.
+func CurlMiddleware(logf func(format string, args ...any)) Middleware {
+	return func(call *Call, next RoundTrip) (*http.Response, error) {
+		r := call.Request
+		h := r.Header.Clone()
+		if h.Get("Authorization") != "" {
+			h.Set("Authorization", "Bearer REDACTED")
+		}
+		logf("%s: %s", call.ID, curl(r.Method, r.URL.String(), h, call.Body))
+		return next(call)
+	}
+}
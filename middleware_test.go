@@ -0,0 +1,140 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Trace") != "outer" {
+			http.Error(w, "missing trace header", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data": [{"embedding": [0.5]}], "usage": {"prompt_tokens": 1, "total_tokens": 1}}`))
+	})
+
+	var log []string
+	trace := func(name string) Middleware {
+		return func(call *Call, next RoundTrip) (*http.Response, error) {
+			log = append(log, name+" "+call.ID+" "+string(call.Body))
+			if name == "outer" {
+				call.Request.Header.Set("X-Trace", name)
+			}
+			resp, err := next(call)
+			if err == nil {
+				log = append(log, fmt.Sprintf("%s %d", name, resp.StatusCode))
+			}
+			return resp, err
+		}
+	}
+	ctx := WithMiddleware(context.Background(), trace("outer"))
+	ctx = WithMiddleware(ctx, trace("inner"))
+
+	_, _, err := ComputeEmbedding(ctx, "Hi", client, Credentials{})
+	if err != nil {
+		t.Fatalf("** ComputeEmbedding: %v", err)
+	}
+	body := `{"model":"text-embedding-ada-002","input":"Hi"}` + "\n"
+	expected := []string{"outer ComputeEmbedding " + body, "inner ComputeEmbedding " + body, "inner 200", "outer 200"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("** log:\n%q\nwanted:\n%q", log, expected)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("** request reached the server")
+	})
+	canned := func(call *Call, next RoundTrip) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"error": {"message": "Slow down", "type": "rate_limit_error"}}`)),
+		}, nil
+	}
+	ctx := WithMiddleware(context.Background(), canned)
+	_, _, err := ComputeEmbedding(ctx, "Hi", client, Credentials{})
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != 429 || e.Type != "rate_limit_error" {
+		t.Errorf("** canned response: %v", err)
+	}
+
+	failing := func(call *Call, next RoundTrip) (*http.Response, error) {
+		return nil, errors.New("offline")
+	}
+	ctx = WithMiddleware(context.Background(), failing)
+	_, _, err = ComputeEmbedding(ctx, "Hi", client, Credentials{})
+	if !errors.As(err, &e) || !e.IsNetwork || e.Cause.Error() != "offline" {
+		t.Errorf("** failing middleware: %v", err)
+	}
+}
+
+func TestMiddlewareShortCircuitUpload(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("** request reached the server")
+	})
+	var body io.Reader
+	failing := func(call *Call, next RoundTrip) (*http.Response, error) {
+		body = call.Request.Body
+		return nil, errors.New("offline")
+	}
+	ctx := WithMiddleware(context.Background(), failing)
+	_, err := UploadFile(ctx, strings.NewReader("{}\n"), "data.jsonl", FilePurposeFineTune, client, Credentials{})
+	if err == nil {
+		t.Fatalf("** UploadFile succeeded")
+	}
+	if _, err := body.Read(make([]byte, 1)); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("** reading the request body after UploadFile: %v, wanted io.ErrClosedPipe", err)
+	}
+
+	// closing the body fails the write blocked on it, so the writer goroutine exits
+	pr, pw := io.Pipe()
+	written := make(chan error, 1)
+	go func() {
+		_, err := pw.Write([]byte("--BOUNDARY\r\n"))
+		written <- err
+	}()
+	r := must(http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/files", pr))
+	if _, err := roundTrip(&Call{ID: "UploadFile", Request: r}, client); err == nil {
+		t.Fatalf("** roundTrip succeeded")
+	}
+	select {
+	case err := <-written:
+		if !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("** writing the body failed with %v, wanted io.ErrClosedPipe", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("** writing the body is stuck")
+	}
+}
+
+func TestCurlMiddleware(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-secret" {
+			http.Error(w, "bad key", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": [{"embedding": [0.5]}]}`))
+	})
+	var lines []string
+	logf := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	ctx := WithMiddleware(context.Background(), CurlMiddleware(logf))
+	_, _, err := ComputeEmbedding(ctx, "Don't", client, Credentials{APIKey: "sk-secret", OrganizationID: "org-1"})
+	if err != nil {
+		t.Fatalf("** ComputeEmbedding: %v", err)
+	}
+	expected := `ComputeEmbedding: curl -i -XPOST -H 'Authorization: Bearer REDACTED' -H 'Content-Type: application/json' -H 'Openai-Organization: org-1' -d "{\"model\":\"text-embedding-ada-002\",\"input\":\"Don't\"}` + "\n" + `" https://api.openai.com/v1/embeddings`
+	if len(lines) != 1 || lines[0] != expected {
+		t.Errorf("** logged:\n%s\nwanted:\n%s", strings.Join(lines, "\n"), expected)
+	}
+}
@@ -0,0 +1,263 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Encodings are names of tokenizer encodings used by OpenAI models.
+//
+// Note that the built-in tokenizer currently only implements EncodingR50k
+// and uses it to approximate token counts for other models, see TokenizerEncoding.
+const (
+	EncodingR50k   = "r50k_base"
+	EncodingP50k   = "p50k_base"
+	EncodingCL100k = "cl100k_base"
+	EncodingO200k  = "o200k_base"
+)
+
+// ErrUnknownModel is returned (wrapped) by lookups of models missing from the registry.
+var ErrUnknownModel = errors.New("unknown model")
+
+// ModelInfo describes the limits, pricing and capabilities of a model.
+// Prices are per 1M tokens, the way OpenAI publishes them.
+type ModelInfo struct {
+	Name string
+
+	// ContextWindow is the maximum number of prompt and completion tokens combined.
+	ContextWindow int
+
+	// MaxOutputTokens is the maximum number of completion tokens, or 0 if only limited by ContextWindow.
+	MaxOutputTokens int
+
+	// Encoding is the tokenizer encoding of the model, e.g. EncodingCL100k.
+	Encoding string
+
+	InputPrice       Price
+	CachedInputPrice Price
+	OutputPrice      Price
+
+	// AudioInputPrice and AudioOutputPrice apply to audio tokens of audio-capable models.
+	AudioInputPrice  Price
+	AudioOutputPrice Price
+
+	// TrainingPrice is the cost of fine-tuning the model, or 0 if it cannot be fine-tuned.
+	TrainingPrice Price
+
+	// FineTunedInputPrice and FineTunedOutputPrice are the prices of using a model fine-tuned from this one.
+	FineTunedInputPrice  Price
+	FineTunedOutputPrice Price
+
+	SupportsTools  bool
+	SupportsVision bool
+	SupportsJSON   bool
+}
+
+var (
+	modelsMut    sync.RWMutex
+	models       = make(map[string]*ModelInfo)
+	modelAliases = make(map[string]string)
+)
+
+// RegisterModel adds a model to the registry consulted by MaxTokens, Cost and friends,
+// replacing any existing model with the same name. Use this to support models released
+// after this version of the library, or to adjust prices. The registry starts out with
+// the models from the embedded models.json; see LoadModelCatalog to load more from a file.
+func RegisterModel(info ModelInfo) {
+	if info.Name == "" {
+		panic("RegisterModel: empty model name")
+	}
+	modelsMut.Lock()
+	defer modelsMut.Unlock()
+	models[info.Name] = &info
+}
+
+// RegisterModelAlias makes lookups of alias resolve to the given model,
+// e.g. to map a custom deployment name onto a known model.
+func RegisterModelAlias(alias, model string) {
+	if alias == "" || model == "" {
+		panic("RegisterModelAlias: empty model name")
+	}
+	modelsMut.Lock()
+	defer modelsMut.Unlock()
+	modelAliases[alias] = model
+}
+
+// LookupModel returns the registry information about the given model.
+//
+// Names that are not registered are resolved in this order:
+// fine-tuned models (ft:gpt-4o-mini-2024-07-18:org::id, or legacy davinci:ft-org-2023-01-01)
+// resolve to their base models with fine-tuned pricing; aliases registered via RegisterModelAlias
+// resolve to their targets; dated snapshots (gpt-4o-2024-05-13, gpt-4-0613) resolve to their
+// generic models. Returns an error wrapping ErrUnknownModel if the model is not known.
+func LookupModel(model string) (ModelInfo, error) {
+	name, fineTuned, ok := resolveModel(model)
+	if !ok {
+		if fineTuned {
+			return ModelInfo{}, fmt.Errorf("%w %q in %q", ErrUnknownModel, BaseModel(model), model)
+		}
+		return ModelInfo{}, fmt.Errorf("%w %q", ErrUnknownModel, model)
+	}
+	info, _ := lookupRegisteredModel(name)
+	if fineTuned {
+		info.InputPrice, info.CachedInputPrice, info.OutputPrice = info.FineTunedInputPrice, info.FineTunedInputPrice, info.FineTunedOutputPrice
+	}
+	return info, nil
+}
+
+// ModelEncoding returns the tokenizer encoding of the given model, e.g. EncodingCL100k.
+func ModelEncoding(model string) (string, error) {
+	info, err := LookupModel(model)
+	if err != nil {
+		return "", err
+	}
+	return info.Encoding, nil
+}
+
+// BaseModel returns the model that the given fine-tuned model was trained from,
+// e.g. gpt-4o-mini-2024-07-18 for ft:gpt-4o-mini-2024-07-18:org::id. Returns
+// other model names unchanged.
+func BaseModel(model string) string {
+	if rest, ok := strings.CutPrefix(model, "ft:"); ok {
+		base, _, _ := strings.Cut(rest, ":")
+		return base
+	}
+	if base, _, ok := strings.Cut(model, ":ft-"); ok {
+		return base
+	}
+	return model
+}
+
+// maxAliasDepth limits alias resolution in case aliases form a cycle.
+const maxAliasDepth = 10
+
+var snapshotRe = regexp.MustCompile(`^(.+)-(?:\d{4}-\d{2}-\d{2}|\d{4})$`)
+
+// resolveModel returns the name of the registered model the given model refers to,
+// and whether it's a fine-tuned model.
+func resolveModel(model string) (name string, fineTuned bool, ok bool) {
+	if base := BaseModel(model); base != model {
+		model, fineTuned = base, true
+	}
+	for i := 0; i < maxAliasDepth; i++ {
+		if _, found := lookupRegisteredModel(model); found {
+			return model, fineTuned, true
+		}
+		if target, found := lookupModelAlias(model); found {
+			model = target
+		} else if m := snapshotRe.FindStringSubmatch(model); m != nil {
+			model = m[1]
+		} else {
+			break
+		}
+	}
+	return "", fineTuned, false
+}
+
+func lookupModelAlias(alias string) (string, bool) {
+	modelsMut.RLock()
+	defer modelsMut.RUnlock()
+	target, ok := modelAliases[alias]
+	return target, ok
+}
+
+func lookupRegisteredModel(model string) (ModelInfo, bool) {
+	modelsMut.RLock()
+	defer modelsMut.RUnlock()
+	if info := models[model]; info != nil {
+		return *info, true
+	}
+	return ModelInfo{}, false
+}
+
+// MaxTokensE is like MaxTokens, but returns an error for unknown models instead of panicking.
+func MaxTokensE(model string) (int, error) {
+	info, err := LookupModel(model)
+	if err != nil {
+		return 0, err
+	}
+	return info.ContextWindow, nil
+}
+
+// CostE is like Cost, but returns an error for unknown models instead of panicking.
+func CostE(promptTokens, completionTokens int, model string) (Price, error) {
+	info, err := LookupModel(model)
+	if err != nil {
+		return 0, err
+	}
+	if info.InputPrice == 0 && info.OutputPrice == 0 {
+		return 0, fmt.Errorf("no pricing known for model %q", model)
+	}
+	return perMillion(promptTokens, info.InputPrice) + perMillion(completionTokens, info.OutputPrice), nil
+}
+
+// CostBreakdown is the cost of a call split by token kind, see UsageCost.
+type CostBreakdown struct {
+	Input       Price // uncached text (and image) prompt tokens
+	CachedInput Price
+	AudioInput  Price
+	Output      Price // text completion tokens, including rejected predictions
+	Reasoning   Price // billed at the output price, but not returned in the response
+	AudioOutput Price
+}
+
+// Total returns the total cost.
+func (c CostBreakdown) Total() Price {
+	return c.Input + c.CachedInput + c.AudioInput + c.Output + c.Reasoning + c.AudioOutput
+}
+
+// UsageCost computes the cost of the given usage with the given model, pricing cached input,
+// reasoning and audio tokens separately. Cached tokens are charged the full input price
+// if the model has no cached input price.
+func UsageCost(usage Usage, model string) (CostBreakdown, error) {
+	info, err := LookupModel(model)
+	if err != nil {
+		return CostBreakdown{}, err
+	}
+	if info.InputPrice == 0 && info.OutputPrice == 0 {
+		return CostBreakdown{}, fmt.Errorf("no pricing known for model %q", model)
+	}
+	pd, cd := usage.PromptTokensDetails, usage.CompletionTokensDetails
+	if (pd.AudioTokens > 0 && info.AudioInputPrice == 0) || (cd.AudioTokens > 0 && info.AudioOutputPrice == 0) {
+		return CostBreakdown{}, fmt.Errorf("no audio pricing known for model %q", model)
+	}
+
+	cachedPrice := info.CachedInputPrice
+	if cachedPrice == 0 {
+		cachedPrice = info.InputPrice
+	}
+	return CostBreakdown{
+		Input:       perMillion(usage.PromptTokens-pd.CachedTokens-pd.AudioTokens, info.InputPrice),
+		CachedInput: perMillion(pd.CachedTokens, cachedPrice),
+		AudioInput:  perMillion(pd.AudioTokens, info.AudioInputPrice),
+		Output:      perMillion(usage.CompletionTokens-cd.ReasoningTokens-cd.AudioTokens, info.OutputPrice),
+		Reasoning:   perMillion(cd.ReasoningTokens, info.OutputPrice),
+		AudioOutput: perMillion(cd.AudioTokens, info.AudioOutputPrice),
+	}, nil
+}
+
+// FineTuningCostE is like FineTuningCost, but returns an error for unknown models instead of panicking.
+func FineTuningCostE(tokens int, model string) (Price, error) {
+	info, err := LookupModel(model)
+	if err != nil {
+		return 0, err
+	}
+	if info.TrainingPrice == 0 {
+		return 0, fmt.Errorf("model %q cannot be fine-tuned", model)
+	}
+	return perMillion(tokens, info.TrainingPrice), nil
+}
+
+// perMillion computes the price of the given number of tokens given a price per 1M tokens.
+func perMillion(tokens int, price Price) Price {
+	return Price(int64(tokens) * int64(price) / 1_000_000)
+}
+
+// usdPerM converts a price in dollars per 1M tokens into Price.
+func usdPerM(dollars float64) Price {
+	return Price(math.Round(dollars * 100_000_000))
+}
@@ -0,0 +1,93 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// Model is a model available to the API key, as returned by ListModels.
+// See LookupModel for the limits and pricing of a model.
+type Model struct {
+	ID      string `json:"id"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"` // "openai", "system" or the organization of a fine-tuned model
+}
+
+// ListModels returns all models available to the API key, including fine-tuned ones.
+func ListModels(ctx context.Context, client *http.Client, creds Credentials) ([]*Model, error) {
+	const callID = "ListModels"
+	var resp listResponse[*Model]
+	err := get(ctx, callID, "https://api.openai.com/v1/models", client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// RetrieveModel returns information about a single model.
+func RetrieveModel(ctx context.Context, model string, client *http.Client, creds Credentials) (*Model, error) {
+	const callID = "RetrieveModel"
+	var resp Model
+	err := get(ctx, callID, "https://api.openai.com/v1/models/"+url.PathEscape(model), client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteModel deletes a fine-tuned model. Requires the Owner role in the organization.
+func DeleteModel(ctx context.Context, model string, client *http.Client, creds Credentials) error {
+	const callID = "DeleteModel"
+	var resp deleteResponse
+	err := del(ctx, callID, "https://api.openai.com/v1/models/"+url.PathEscape(model), client, creds, &resp)
+	if err != nil {
+		return err
+	}
+	if !resp.Deleted {
+		return &Error{
+			CallID:  callID,
+			Message: "model was not deleted",
+		}
+	}
+	return nil
+}
+
+// ModelGap is a model that MaxTokens or Cost would panic on.
+type ModelGap struct {
+	Model string
+	Err   error // wraps ErrUnknownModel for unregistered models
+}
+
+// FindModelGaps returns the models that are missing from the registry or have no
+// token pricing, sorted by name. Run it against ListModels to catch models that your
+// key can use but this library doesn't know about, and fill the gaps with RegisterModel
+// or LoadModelCatalog.
+//
+// Note that image, audio and moderation models are not priced per token and are
+// therefore always reported unless registered with custom prices.
+func FindModelGaps(available []*Model) []ModelGap {
+	var gaps []ModelGap
+	for _, m := range available {
+		if _, err := MaxTokensE(m.ID); err != nil {
+			gaps = append(gaps, ModelGap{m.ID, err})
+		} else if _, err := CostE(0, 0, m.ID); err != nil {
+			gaps = append(gaps, ModelGap{m.ID, err})
+		}
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		return gaps[i].Model < gaps[j].Model
+	})
+	return gaps
+}
+
+// CheckAvailableModels lists the models available to the API key and returns
+// those FindModelGaps reports.
+func CheckAvailableModels(ctx context.Context, client *http.Client, creds Credentials) ([]ModelGap, error) {
+	available, err := ListModels(ctx, client, creds)
+	if err != nil {
+		return nil, err
+	}
+	return FindModelGaps(available), nil
+}
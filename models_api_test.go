@@ -0,0 +1,54 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestModelsAPI(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/models":
+			w.Write([]byte(`{"object": "list", "data": [
+				{"id": "gpt-4o", "object": "model", "created": 1715367049, "owned_by": "system"},
+				{"id": "gpt-4o-2024-08-06", "object": "model", "created": 1722814719, "owned_by": "system"},
+				{"id": "ft:gpt-4o-mini-2024-07-18:acme::abc123", "object": "model", "created": 1730000000, "owned_by": "acme"},
+				{"id": "whisper-1", "object": "model", "created": 1677532384, "owned_by": "openai-internal"},
+				{"id": "gpt-9", "object": "model", "created": 1900000000, "owned_by": "system"}
+			]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/models/gpt-4o":
+			w.Write([]byte(`{"id": "gpt-4o", "object": "model", "created": 1715367049, "owned_by": "system"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/models/ft:gpt-4o-mini-2024-07-18:acme::abc123":
+			w.Write([]byte(`{"id": "ft:gpt-4o-mini-2024-07-18:acme::abc123", "object": "model", "deleted": true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	m, err := RetrieveModel(ctx, ModelChatGPT4o, client, Credentials{})
+	if err != nil || m.ID != ModelChatGPT4o || m.OwnedBy != "system" {
+		t.Errorf("** RetrieveModel = %+v, %v", m, err)
+	}
+
+	if err := DeleteModel(ctx, "ft:gpt-4o-mini-2024-07-18:acme::abc123", client, Credentials{}); err != nil {
+		t.Errorf("** DeleteModel: %v", err)
+	}
+
+	gaps, err := CheckAvailableModels(ctx, client, Credentials{})
+	if err != nil {
+		t.Fatalf("** CheckAvailableModels: %v", err)
+	}
+	var names []string
+	for _, g := range gaps {
+		names = append(names, g.Model)
+		if !errors.Is(g.Err, ErrUnknownModel) {
+			t.Errorf("** %s: %v, wanted ErrUnknownModel", g.Model, g.Err)
+		}
+	}
+	if len(names) != 2 || names[0] != "gpt-9" || names[1] != ModelWhisper1 {
+		t.Errorf("** gaps = %v, wanted [gpt-9 whisper-1]", names)
+	}
+}
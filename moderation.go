@@ -0,0 +1,138 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"sort"
+)
+
+// Moderation categories, used as keys of ModerationResult maps.
+const (
+	ModerationHarassment            = "harassment"
+	ModerationHarassmentThreatening = "harassment/threatening"
+	ModerationHate                  = "hate"
+	ModerationHateThreatening       = "hate/threatening"
+	ModerationIllicit               = "illicit"
+	ModerationIllicitViolent        = "illicit/violent"
+	ModerationSelfHarm              = "self-harm"
+	ModerationSelfHarmIntent        = "self-harm/intent"
+	ModerationSelfHarmInstructions  = "self-harm/instructions"
+	ModerationSexual                = "sexual"
+	ModerationSexualMinors          = "sexual/minors"
+	ModerationViolence              = "violence"
+	ModerationViolenceGraphic       = "violence/graphic"
+)
+
+// ModerationInput is a piece of text or an image to screen. Set exactly one field.
+type ModerationInput struct {
+	Text string
+
+	// ImageURL is either a URL or a data: URL with base64-encoded image.
+	// Only supported by ModelModerationOmni.
+	ImageURL string
+}
+
+// ModerationResult is the verdict on a single ModerationInput.
+type ModerationResult struct {
+	// Flagged is whether any of the categories are flagged.
+	Flagged bool `json:"flagged"`
+
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+
+	// CategoryAppliedInputTypes lists input types ("text", "image") that contributed
+	// to each category. Only returned by ModelModerationOmni.
+	CategoryAppliedInputTypes map[string][]string `json:"category_applied_input_types,omitempty"`
+}
+
+// FlaggedCategories returns the names of the flagged categories.
+func (r *ModerationResult) FlaggedCategories() []string {
+	var result []string
+	for category, flagged := range r.Categories {
+		if flagged {
+			result = append(result, category)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Moderate classifies the inputs as potentially harmful. Model is ModelModerationOmni
+// or ModelModerationText; empty uses ModelModerationOmni. Moderation calls are free.
+//
+// The Omni model combines all inputs into a single verdict, so a single result is returned
+// when images are present; otherwise, there is one result per input.
+func Moderate(ctx context.Context, inputs []ModerationInput, model string, client *http.Client, creds Credentials) ([]*ModerationResult, error) {
+	const callID = "Moderate"
+	if model == "" {
+		model = ModelModerationOmni
+	}
+
+	req := &moderationRequest{Model: model}
+	var hasImages bool
+	for _, in := range inputs {
+		if in.ImageURL != "" {
+			hasImages = true
+		}
+	}
+	if hasImages {
+		parts := make([]moderationInputPart, 0, len(inputs))
+		for _, in := range inputs {
+			if in.ImageURL != "" {
+				parts = append(parts, moderationInputPart{Type: "image_url", ImageURL: &moderationImageURL{in.ImageURL}})
+			} else {
+				parts = append(parts, moderationInputPart{Type: "text", Text: in.Text})
+			}
+		}
+		req.Input = parts
+	} else {
+		texts := make([]string, 0, len(inputs))
+		for _, in := range inputs {
+			texts = append(texts, in.Text)
+		}
+		req.Input = texts
+	}
+
+	var resp moderationResponse
+	err := post(ctx, callID, "https://api.openai.com/v1/moderations", client, creds, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, &Error{
+			CallID:  callID,
+			Message: "no results",
+		}
+	}
+	return resp.Results, nil
+}
+
+// ModerateText is a shortcut for checking a single piece of text with ModelModerationOmni.
+func ModerateText(ctx context.Context, text string, client *http.Client, creds Credentials) (*ModerationResult, error) {
+	results, err := Moderate(ctx, []ModerationInput{{Text: text}}, "", client, creds)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+type moderationRequest struct {
+	Model string `json:"model"`
+	Input any    `json:"input"`
+}
+
+type moderationInputPart struct {
+	Type     string              `json:"type"`
+	Text     string              `json:"text,omitempty"`
+	ImageURL *moderationImageURL `json:"image_url,omitempty"`
+}
+
+type moderationImageURL struct {
+	URL string `json:"url"`
+}
+
+type moderationResponse struct {
+	ID      string              `json:"id"`
+	Model   string              `json:"model"`
+	Results []*ModerationResult `json:"results"`
+}
@@ -0,0 +1,52 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestModerate(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/moderations" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+		w.Write([]byte(`{"id": "modr-1", "model": "omni-moderation-latest", "results": [{
+			"flagged": true,
+			"categories": {"hate": false, "violence": true, "harassment": true},
+			"category_scores": {"hate": 0.01, "violence": 0.93, "harassment": 0.71},
+			"category_applied_input_types": {"violence": ["text", "image"]}
+		}]}`))
+	})
+	ctx := context.Background()
+
+	r, err := ModerateText(ctx, "hi", client, Credentials{})
+	if err != nil {
+		t.Fatalf("** ModerateText: %v", err)
+	}
+	if !r.Flagged || r.CategoryScores[ModerationViolence] != 0.93 || !reflect.DeepEqual(r.CategoryAppliedInputTypes[ModerationViolence], []string{"text", "image"}) {
+		t.Errorf("** ModerateText = %+v", r)
+	}
+	if a, e := r.FlaggedCategories(), []string{ModerationHarassment, ModerationViolence}; !reflect.DeepEqual(a, e) {
+		t.Errorf("** FlaggedCategories = %v, wanted %v", a, e)
+	}
+
+	_, err = Moderate(ctx, []ModerationInput{{Text: "look"}, {ImageURL: "https://example.com/a.png"}}, "", client, Credentials{})
+	if err != nil {
+		t.Fatalf("** Moderate: %v", err)
+	}
+
+	expected := []string{
+		`{"model":"omni-moderation-latest","input":["hi"]}` + "\n",
+		`{"model":"omni-moderation-latest","input":[{"type":"text","text":"look"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}` + "\n",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("** requests:\n%q\nwanted:\n%q", requests, expected)
+	}
+}
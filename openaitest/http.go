@@ -0,0 +1,85 @@
+package openaitest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func readAll(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	return io.ReadAll(r.Body)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, typ, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(must(json.Marshal(map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    typ,
+			"param":   nil,
+			"code":    nil,
+		},
+	})))
+}
+
+func writeJSON(w http.ResponseWriter, fault Fault, v any) {
+	data := must(json.Marshal(v))
+	w.Header().Set("Content-Type", "application/json")
+	switch fault {
+	case MalformedJSON:
+		data = data[:len(data)/2]
+	case TruncatedStream:
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data[:len(data)/2])
+		abort(w)
+	}
+	w.Write(data)
+}
+
+func writeStream(w http.ResponseWriter, fault Fault, events []any) {
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	for i, ev := range events {
+		data := must(json.Marshal(ev))
+		if i == 1 && fault == MalformedJSON {
+			data = data[:len(data)/2]
+		}
+		if i == len(events)/2 && fault == TruncatedStream {
+			w.Write([]byte("data: "))
+			w.Write(data[:len(data)/2])
+			abort(w)
+		}
+		w.Write([]byte("data: "))
+		w.Write(data)
+		w.Write([]byte("\n\n"))
+		w.(http.Flusher).Flush()
+	}
+	w.Write([]byte("data: [DONE]\n\n"))
+}
+
+// abort flushes what has been written so far and drops the connection.
+func abort(w http.ResponseWriter) {
+	w.(http.Flusher).Flush()
+	panic(http.ErrAbortHandler)
+}
+
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,437 @@
+// Package openaitest provides a fake OpenAI API server for testing code that uses
+// package openai without network access.
+//
+// The server implements chat completions (streaming and non-streaming), completions
+// and embeddings. Responses can be scripted per endpoint, including injected failures,
+// and their Usage is computed with the tokenizer of package openai:
+//
+//	srv := openaitest.NewServer()
+//	defer srv.Close()
+//	srv.Enqueue(openaitest.ChatEndpoint, openaitest.Reply{Fault: openaitest.RateLimit}, openaitest.Reply{Content: "Hi!"})
+//	msgs, usage, err := openai.Chat(ctx, msgs, opt, srv.Client(), openai.Credentials{})
+package openaitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andreyvit/openai"
+)
+
+// Endpoints implemented by Server.
+const (
+	ChatEndpoint       = "/v1/chat/completions"
+	CompletionEndpoint = "/v1/completions"
+	EmbeddingEndpoint  = "/v1/embeddings"
+)
+
+// Fault is a failure to inject into a response.
+type Fault int
+
+const (
+	NoFault Fault = iota
+
+	// RateLimit fails with HTTP 429 and a Retry-After header.
+	RateLimit
+
+	// ServerError fails with HTTP 500.
+	ServerError
+
+	// MalformedJSON responds with HTTP 200 and a body that isn't valid JSON
+	// (when streaming, a chunk that isn't valid JSON follows the first one).
+	MalformedJSON
+
+	// TruncatedStream drops the connection halfway through the response
+	// (when streaming, in the middle of an event).
+	TruncatedStream
+)
+
+// Reply is a scripted response, see Server.Enqueue.
+type Reply struct {
+	// Content is the assistant message of a chat completion or the text of a completion.
+	Content string
+
+	// Chunks are the content deltas of a streaming chat completion; they default to
+	// Content split into tokens. If Content is empty, it's the concatenation of Chunks.
+	Chunks []string
+
+	// FinishReason defaults to openai.FinishReasonStop.
+	FinishReason openai.FinishReason
+
+	// Embedding is returned for every input of an embeddings request; defaults to
+	// a unit vector derived from the input.
+	Embedding []float64
+
+	Fault Fault
+
+	// RetryAfter is the Retry-After delay of a RateLimit fault; defaults to 1 second.
+	RetryAfter time.Duration
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Server is a fake OpenAI API server. Requests without a scripted reply get
+// DefaultReply. Safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// DefaultReply is used when there are no scripted replies for an endpoint.
+	// NewServer sets its Content to "OK".
+	DefaultReply Reply
+
+	// Dimensions is the size of generated embeddings; NewServer sets it to 1536.
+	Dimensions int
+
+	mut      sync.Mutex
+	replies  map[string][]Reply
+	requests []Request
+}
+
+// NewServer starts a fake server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		DefaultReply: Reply{Content: "OK"},
+		Dimensions:   1536,
+		replies:      make(map[string][]Reply),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client returns an HTTP client that sends API calls to the fake server instead of api.openai.com.
+func (s *Server) Client() *http.Client {
+	return &http.Client{Transport: &redirectTransport{must(url.Parse(s.URL))}}
+}
+
+// Enqueue scripts replies to the next requests to the given endpoint, e.g. ChatEndpoint.
+// Replies are used in order, once each.
+func (s *Server) Enqueue(endpoint string, replies ...Reply) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.replies[endpoint] = append(s.replies[endpoint], replies...)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) nextReply(endpoint string) (Reply, int) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	reply := s.DefaultReply
+	if q := s.replies[endpoint]; len(q) > 0 {
+		reply, s.replies[endpoint] = q[0], q[1:]
+	}
+	return reply, len(s.requests)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, err := readAll(r)
+	if err != nil {
+		return
+	}
+	s.mut.Lock()
+	s.requests = append(s.requests, Request{r.Method, r.URL.Path, r.Header.Clone(), body})
+	s.mut.Unlock()
+
+	switch r.URL.Path {
+	case ChatEndpoint, CompletionEndpoint, EmbeddingEndpoint:
+	default:
+		writeError(w, http.StatusNotFound, "invalid_request_error", "Invalid URL ("+r.Method+" "+r.URL.Path+")")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "Method not allowed")
+		return
+	}
+
+	reply, n := s.nextReply(r.URL.Path)
+	w.Header().Set("X-Request-Id", fmt.Sprintf("req_%d", n))
+	switch reply.Fault {
+	case RateLimit:
+		d := reply.RetryAfter
+		if d == 0 {
+			d = time.Second
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "requests", "Rate limit reached, please try again later")
+		return
+	case ServerError:
+		writeError(w, http.StatusInternalServerError, "server_error", "The server had an error while processing your request")
+		return
+	}
+
+	switch r.URL.Path {
+	case ChatEndpoint:
+		s.chat(w, body, reply)
+	case CompletionEndpoint:
+		s.complete(w, body, reply)
+	case EmbeddingEndpoint:
+		s.embed(w, body, reply)
+	}
+}
+
+type chatRequest struct {
+	Messages []openai.Msg `json:"messages"`
+	openai.Options
+	Stream        bool `json:"stream"`
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options"`
+}
+
+func (s *Server) chat(w http.ResponseWriter, body []byte, reply Reply) {
+	var req chatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "We could not parse the JSON body of your request: "+err.Error())
+		return
+	}
+	if req.Model == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "you must provide a model parameter")
+		return
+	}
+	if len(req.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "[] is too short - 'messages'")
+		return
+	}
+	n := req.N
+	if n == 0 {
+		n = 1
+	}
+	finish := reply.FinishReason
+	if finish == "" {
+		finish = openai.FinishReasonStop
+	}
+	content := reply.Content
+	if content == "" {
+		content = strings.Join(reply.Chunks, "")
+	}
+	completionTokens := openai.TokenCount(content, req.Model)
+	usage := makeUsage(openai.ChatPromptTokenCount(req.Messages, req.Options), n*completionTokens)
+
+	if !req.Stream {
+		choices := make([]map[string]any, n)
+		for i := range choices {
+			choices[i] = map[string]any{
+				"index":         i,
+				"message":       openai.Msg{Role: openai.Assistant, Content: content},
+				"finish_reason": finish,
+			}
+		}
+		writeJSON(w, reply.Fault, map[string]any{
+			"id":      "chatcmpl-fake",
+			"object":  "chat.completion",
+			"created": time.Now().Unix(),
+			"model":   req.Model,
+			"choices": choices,
+			"usage":   usage,
+		})
+		return
+	}
+
+	chunks := reply.Chunks
+	if chunks == nil {
+		chunks = splitTokens(content, req.Model)
+	}
+	chunk := func(delta map[string]any, finish any) map[string]any {
+		return map[string]any{
+			"id":      "chatcmpl-fake",
+			"object":  "chat.completion.chunk",
+			"created": time.Now().Unix(),
+			"model":   req.Model,
+			"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finish}},
+		}
+	}
+	events := []any{chunk(map[string]any{"role": openai.Assistant, "content": ""}, nil)}
+	for _, c := range chunks {
+		events = append(events, chunk(map[string]any{"content": c}, nil))
+	}
+	events = append(events, chunk(map[string]any{}, finish))
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		events = append(events, map[string]any{
+			"id":      "chatcmpl-fake",
+			"object":  "chat.completion.chunk",
+			"created": time.Now().Unix(),
+			"model":   req.Model,
+			"choices": []any{},
+			"usage":   usage,
+		})
+	}
+	writeStream(w, reply.Fault, events)
+}
+
+type completionRequest struct {
+	Prompt any `json:"prompt"` // string or []string
+	openai.Options
+}
+
+func (s *Server) complete(w http.ResponseWriter, body []byte, reply Reply) {
+	var req completionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "We could not parse the JSON body of your request: "+err.Error())
+		return
+	}
+	if req.Model == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "you must provide a model parameter")
+		return
+	}
+	prompts, ok := stringOrStrings(req.Prompt)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "'prompt' must be a string or an array of strings")
+		return
+	}
+	n := req.N
+	if n == 0 {
+		n = 1
+	}
+	finish := reply.FinishReason
+	if finish == "" {
+		finish = openai.FinishReasonStop
+	}
+
+	var promptTokens int
+	var choices []map[string]any
+	for _, prompt := range prompts {
+		promptTokens += openai.TokenCount(prompt, req.Model)
+		for j := 0; j < n; j++ {
+			choices = append(choices, map[string]any{
+				"index":         len(choices),
+				"text":          reply.Content,
+				"logprobs":      nil,
+				"finish_reason": finish,
+			})
+		}
+	}
+	writeJSON(w, reply.Fault, map[string]any{
+		"id":      "cmpl-fake",
+		"object":  "text_completion",
+		"created": time.Now().Unix(),
+		"model":   req.Model,
+		"choices": choices,
+		"usage":   makeUsage(promptTokens, len(choices)*openai.TokenCount(reply.Content, req.Model)),
+	})
+}
+
+type embeddingRequest struct {
+	Model string `json:"model"`
+	Input any    `json:"input"` // string or []string
+}
+
+func (s *Server) embed(w http.ResponseWriter, body []byte, reply Reply) {
+	var req embeddingRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "We could not parse the JSON body of your request: "+err.Error())
+		return
+	}
+	if req.Model == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "you must provide a model parameter")
+		return
+	}
+	inputs, ok := stringOrStrings(req.Input)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "'input' must be a string or an array of strings")
+		return
+	}
+
+	var tokens int
+	data := make([]map[string]any, len(inputs))
+	for i, input := range inputs {
+		tokens += openai.TokenCount(input, req.Model)
+		emb := reply.Embedding
+		if emb == nil {
+			emb = fakeEmbedding(input, s.Dimensions)
+		}
+		data[i] = map[string]any{"object": "embedding", "index": i, "embedding": emb}
+	}
+	writeJSON(w, reply.Fault, map[string]any{
+		"object": "list",
+		"model":  req.Model,
+		"data":   data,
+		"usage":  map[string]any{"prompt_tokens": tokens, "total_tokens": tokens},
+	})
+}
+
+func makeUsage(promptTokens, completionTokens int) openai.Usage {
+	return openai.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// splitTokens splits text into tokens, merging tokens that end in the middle of a UTF-8 character.
+func splitTokens(text, model string) []string {
+	var result []string
+	start := 0
+	for _, span := range openai.EncodeWithOffsets(text, model) {
+		if s := text[start:span.End]; utf8.ValidString(s) {
+			result = append(result, s)
+			start = span.End
+		}
+	}
+	if start < len(text) {
+		result = append(result, text[start:])
+	}
+	return result
+}
+
+// fakeEmbedding derives a deterministic unit vector from the input, so that
+// equal inputs have equal embeddings.
+func fakeEmbedding(input string, dimensions int) []float64 {
+	h := fnv.New64a()
+	h.Write([]byte(input))
+	seed := h.Sum64()
+	result := make([]float64, dimensions)
+	var norm float64
+	for i := range result {
+		// xorshift
+		seed ^= seed << 13
+		seed ^= seed >> 7
+		seed ^= seed << 17
+		result[i] = float64(int64(seed>>11))/float64(1<<52) - 1
+		norm += result[i] * result[i]
+	}
+	norm = math.Sqrt(norm)
+	for i := range result {
+		result[i] /= norm
+	}
+	return result
+}
+
+func stringOrStrings(v any) ([]string, bool) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, true
+	case []any:
+		result := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			result[i] = s
+		}
+		return result, len(result) > 0
+	default:
+		return nil, false
+	}
+}
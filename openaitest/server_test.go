@@ -0,0 +1,257 @@
+package openaitest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andreyvit/openai"
+)
+
+func chatOptions() openai.Options {
+	opt := openai.DefaultChatOptions()
+	opt.Model = openai.ModelChatGPT4oMini
+	return opt
+}
+
+func TestChat(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Enqueue(ChatEndpoint, Reply{Content: "Hello there!"})
+	ctx := context.Background()
+
+	msgs := []openai.Msg{openai.SystemMsg("Be nice."), openai.UserMsg("Hi")}
+	result, usage, err := openai.Chat(ctx, msgs, chatOptions(), srv.Client(), openai.Credentials{APIKey: "sk-test"})
+	if err != nil {
+		t.Fatalf("** Chat: %v", err)
+	}
+	if len(result) != 1 || result[0].Role != openai.Assistant || result[0].Content != "Hello there!" {
+		t.Errorf("** Chat = %+v", result)
+	}
+	expected := openai.Usage{
+		PromptTokens:     openai.ChatPromptTokenCount(msgs, chatOptions()),
+		CompletionTokens: openai.TokenCount("Hello there!", openai.ModelChatGPT4oMini),
+	}
+	expected.TotalTokens = expected.PromptTokens + expected.CompletionTokens
+	if usage != expected {
+		t.Errorf("** usage = %+v, wanted %+v", usage, expected)
+	}
+
+	// queue is empty, so the default reply is used
+	result, _, err = openai.Chat(ctx, msgs, chatOptions(), srv.Client(), openai.Credentials{})
+	if err != nil || result[0].Content != "OK" {
+		t.Errorf("** Chat with default reply = %+v, %v", result, err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 || reqs[0].Path != ChatEndpoint || reqs[0].Header.Get("Authorization") != "Bearer sk-test" || !strings.Contains(string(reqs[0].Body), `"Be nice."`) {
+		t.Errorf("** Requests = %+v", reqs)
+	}
+}
+
+func TestStreamChat(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Enqueue(ChatEndpoint, Reply{Content: "Привет, world"}, Reply{Chunks: []string{"A", "B"}, Content: "AB"})
+	ctx := context.Background()
+
+	var deltas []string
+	f := func(msg *openai.Msg, delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	}
+	msg, err := openai.StreamChat(ctx, []openai.Msg{openai.UserMsg("Hi")}, chatOptions(), srv.Client(), openai.Credentials{}, f)
+	if err != nil || msg.Role != openai.Assistant || msg.Content != "Привет, world" {
+		t.Fatalf("** StreamChat = %+v, %v", msg, err)
+	}
+	if len(deltas) < 4 || strings.Join(deltas, "") != msg.Content {
+		t.Errorf("** deltas = %q", deltas)
+	}
+
+	// usage is included when the call is tracked
+	deltas = nil
+	tracker := &openai.CostTracker{}
+	msg, err = openai.StreamChat(openai.WithCostTracker(ctx, tracker, ""), []openai.Msg{openai.UserMsg("Hi")}, chatOptions(), srv.Client(), openai.Credentials{}, f)
+	if err != nil || msg.Content != "AB" || !reflect.DeepEqual(deltas, []string{"", "A", "B", ""}) {
+		t.Errorf("** StreamChat = %+v, %v, deltas %q", msg, err, deltas)
+	}
+	if stats := tracker.Total(); stats.Usage.CompletionTokens != openai.TokenCount("AB", openai.ModelChatGPT4oMini) || stats.Usage.PromptTokens == 0 {
+		t.Errorf("** tracked usage = %+v", stats.Usage)
+	}
+
+	// Content defaults to the concatenated chunks, also for non-streaming calls
+	srv.Enqueue(ChatEndpoint, Reply{Chunks: []string{"Hello", " there"}})
+	result, usage, err := openai.Chat(ctx, []openai.Msg{openai.UserMsg("Hi")}, chatOptions(), srv.Client(), openai.Credentials{})
+	if err != nil || result[0].Content != "Hello there" || usage.CompletionTokens != openai.TokenCount("Hello there", openai.ModelChatGPT4oMini) {
+		t.Errorf("** Chat with chunks only = %+v, %+v, %v", result, usage, err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Enqueue(CompletionEndpoint, Reply{Content: " world", FinishReason: openai.FinishReasonLength})
+
+	opt := openai.DefaultCompleteOptions()
+	opt.Model = "davinci-002"
+	result, usage, err := openai.Complete(context.Background(), "Hello", opt, srv.Client(), openai.Credentials{})
+	if err != nil || len(result) != 1 || result[0].Text != " world" || result[0].FinishReason != openai.FinishReasonLength {
+		t.Fatalf("** Complete = %+v, %v", result, err)
+	}
+	if usage.PromptTokens != 1 || usage.CompletionTokens != 1 || usage.TotalTokens != 2 {
+		t.Errorf("** usage = %+v", usage)
+	}
+}
+
+func TestEmbedding(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Enqueue(EmbeddingEndpoint, Reply{Embedding: []float64{0.6, 0.8}})
+	ctx := context.Background()
+
+	emb, usage, err := openai.ComputeEmbedding(ctx, "Hello world", srv.Client(), openai.Credentials{})
+	if err != nil || !reflect.DeepEqual(emb, []float64{0.6, 0.8}) || usage.PromptTokens != 2 || usage.TotalTokens != 2 {
+		t.Errorf("** ComputeEmbedding = %v, %+v, %v", emb, usage, err)
+	}
+
+	a := must2(openai.ComputeEmbedding(ctx, "Hello", srv.Client(), openai.Credentials{}))
+	b := must2(openai.ComputeEmbedding(ctx, "Hello", srv.Client(), openai.Credentials{}))
+	c := must2(openai.ComputeEmbedding(ctx, "Bye", srv.Client(), openai.Credentials{}))
+	if len(a) != 1536 || !reflect.DeepEqual(a, b) || reflect.DeepEqual(a, c) {
+		t.Errorf("** generated embeddings are not deterministic")
+	}
+	var norm float64
+	for _, v := range a {
+		norm += v * v
+	}
+	if math.Abs(norm-1) > 1e-9 {
+		t.Errorf("** generated embedding has norm² %v", norm)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	msgs := []openai.Msg{openai.UserMsg("Hi")}
+	chat := func() error {
+		_, _, err := openai.Chat(ctx, msgs, chatOptions(), srv.Client(), openai.Credentials{})
+		return err
+	}
+	streamChat := func() error {
+		_, err := openai.StreamChat(ctx, msgs, chatOptions(), srv.Client(), openai.Credentials{}, func(msg *openai.Msg, delta string) error {
+			return nil
+		})
+		return err
+	}
+	asError := func(err error) *openai.Error {
+		t.Helper()
+		var e *openai.Error
+		if !errors.As(err, &e) {
+			t.Fatalf("** err = %v, wanted *openai.Error", err)
+		}
+		return e
+	}
+
+	srv.Enqueue(ChatEndpoint, Reply{Fault: RateLimit, RetryAfter: 3 * time.Second})
+	if e := asError(chat()); e.StatusCode != 429 || e.RetryAfter != 3*time.Second || e.Type != "requests" {
+		t.Errorf("** RateLimit: %v (retry after %v)", e, e.RetryAfter)
+	}
+
+	srv.Enqueue(ChatEndpoint, Reply{Fault: ServerError})
+	if e := asError(chat()); e.StatusCode != 500 || e.Type != "server_error" || e.RetryAfter != 0 {
+		t.Errorf("** ServerError: %v", e)
+	}
+
+	srv.Enqueue(ChatEndpoint, Reply{Fault: MalformedJSON})
+	if e := asError(chat()); e.StatusCode != 200 || e.IsNetwork || !strings.Contains(e.Message, "unmashalling") {
+		t.Errorf("** MalformedJSON: %v", e)
+	}
+
+	srv.Enqueue(ChatEndpoint, Reply{Fault: TruncatedStream})
+	if e := asError(chat()); !e.IsNetwork {
+		t.Errorf("** TruncatedStream: %v", e)
+	}
+
+	srv.Enqueue(ChatEndpoint, Reply{Fault: MalformedJSON, Content: "Hello there"})
+	if e := asError(streamChat()); e.StatusCode != 200 || !strings.Contains(e.Message, "chunk") {
+		t.Errorf("** streaming MalformedJSON: %v", e)
+	}
+
+	srv.Enqueue(ChatEndpoint, Reply{Fault: TruncatedStream, Content: "Hello there, how are you?"})
+	if err := streamChat(); err == nil {
+		t.Errorf("** streaming TruncatedStream succeeded")
+	}
+
+	srv.Enqueue(ChatEndpoint, Reply{Fault: RateLimit})
+	if e := asError(streamChat()); e.StatusCode != 429 || e.RetryAfter != time.Second {
+		t.Errorf("** streaming RateLimit: %v", e)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Enqueue(ChatEndpoint, Reply{Fault: RateLimit}, Reply{Fault: ServerError}, Reply{Content: "Finally"})
+
+	var attempts int
+	var result []openai.Msg
+	for {
+		attempts++
+		var err error
+		result, _, err = openai.Chat(context.Background(), []openai.Msg{openai.UserMsg("Hi")}, chatOptions(), srv.Client(), openai.Credentials{})
+		var e *openai.Error
+		if errors.As(err, &e) && (e.StatusCode == 429 || e.StatusCode >= 500) && attempts < 5 {
+			continue // a real caller would sleep for e.RetryAfter
+		} else if err != nil {
+			t.Fatalf("** Chat: %v", err)
+		}
+		break
+	}
+	if attempts != 3 || result[0].Content != "Finally" {
+		t.Errorf("** %d attempts, result %+v", attempts, result)
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	_, _, err := openai.Chat(ctx, nil, chatOptions(), srv.Client(), openai.Credentials{})
+	var e *openai.Error
+	if !errors.As(err, &e) || e.StatusCode != 400 || e.Type != "invalid_request_error" {
+		t.Errorf("** Chat without messages: %v", err)
+	}
+
+	_, err = openai.ListModels(ctx, srv.Client(), openai.Credentials{})
+	if !errors.As(err, &e) || e.StatusCode != 404 {
+		t.Errorf("** unsupported endpoint: %v", err)
+	}
+}
+
+func TestSplitTokens(t *testing.T) {
+	for _, s := range []string{"", "Hello, world", "Привет 🙂!"} {
+		chunks := splitTokens(s, openai.ModelChatGPT4oMini)
+		if strings.Join(chunks, "") != s {
+			t.Errorf("** splitTokens(%q) = %q", s, chunks)
+		}
+		for _, c := range chunks {
+			if c == "" || !utf8.ValidString(c) {
+				t.Errorf("** splitTokens(%q) = %q", s, chunks)
+			}
+		}
+	}
+}
+
+func must2[T any](v T, _ openai.Usage, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,297 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// RealtimeConn is a Realtime API session over a WebSocket. Send methods are safe
+// for concurrent use; Receive must be called from a single goroutine.
+type RealtimeConn struct {
+	ws *wsConn
+}
+
+// ConnectRealtime opens a Realtime API session with the given model, e.g. ModelRealtime.
+//
+// The connection is established via client, which must support HTTP/1.1 protocol
+// upgrades (http.DefaultClient does) and must not have a Timeout, since that would
+// cut the session short. ctx only bounds connection setup; call Close to end the session.
+func ConnectRealtime(ctx context.Context, model string, client *http.Client, creds Credentials) (*RealtimeConn, error) {
+	const callID = "ConnectRealtime"
+	h := make(http.Header)
+	h.Set("Authorization", "Bearer "+creds.APIKey)
+	h.Set("OpenAI-Beta", "realtime=v1")
+	if creds.OrganizationID != "" {
+		h.Set("OpenAI-Organization", creds.OrganizationID)
+	}
+	ws, err := dialWebSocket(ctx, callID, "https://api.openai.com/v1/realtime?model="+url.QueryEscape(model), h, client)
+	if err != nil {
+		return nil, err
+	}
+	return &RealtimeConn{ws}, nil
+}
+
+// Close ends the session.
+func (c *RealtimeConn) Close() error {
+	return c.ws.Close(wsCloseNormal, "")
+}
+
+// Send sends a client event, which must marshal into a JSON object with a "type" field.
+// Use it for events without a dedicated method.
+func (c *RealtimeConn) Send(event any) error {
+	return c.ws.WriteMessage(wsText, bytes.TrimSpace(saneMarshal(event)))
+}
+
+// UpdateSession changes the session configuration. Only non-empty fields are updated.
+func (c *RealtimeConn) UpdateSession(session RealtimeSession) error {
+	return c.Send(&realtimeClientEvent{Type: "session.update", Session: &session})
+}
+
+// AppendAudio adds audio in the session's input format (by default, 24kHz 16-bit
+// mono little-endian PCM) to the input buffer.
+func (c *RealtimeConn) AppendAudio(audio []byte) error {
+	return c.Send(&realtimeClientEvent{Type: "input_audio_buffer.append", Audio: base64.StdEncoding.EncodeToString(audio)})
+}
+
+// CommitAudio turns the input buffer into a user message. Not needed with server
+// voice activity detection, which commits automatically.
+func (c *RealtimeConn) CommitAudio() error {
+	return c.Send(&realtimeClientEvent{Type: "input_audio_buffer.commit"})
+}
+
+// ClearAudio discards the input buffer.
+func (c *RealtimeConn) ClearAudio() error {
+	return c.Send(&realtimeClientEvent{Type: "input_audio_buffer.clear"})
+}
+
+// CreateItem adds an item to the conversation, e.g. a text message or a function call output.
+func (c *RealtimeConn) CreateItem(item RealtimeItem) error {
+	return c.Send(&realtimeClientEvent{Type: "conversation.item.create", Item: &item})
+}
+
+// CreateResponse asks the model to respond. Not needed with server voice activity
+// detection, which triggers responses automatically.
+func (c *RealtimeConn) CreateResponse() error {
+	return c.Send(&realtimeClientEvent{Type: "response.create"})
+}
+
+// CancelResponse interrupts the response in progress.
+func (c *RealtimeConn) CancelResponse() error {
+	return c.Send(&realtimeClientEvent{Type: "response.cancel"})
+}
+
+// Receive waits for the next server event. Errors reported by the server arrive as
+// events with Error set; a Go error means the connection is broken or closed.
+func (c *RealtimeConn) Receive() (*RealtimeEvent, error) {
+	const callID = "RealtimeReceive"
+	for {
+		opcode, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		if opcode != wsText {
+			continue
+		}
+		ev := &RealtimeEvent{Raw: data}
+		if err := json.Unmarshal(data, ev); err != nil {
+			return nil, &Error{
+				CallID:            callID,
+				Message:           "error unmashalling event",
+				RawResponseBody:   data,
+				PrintResponseBody: true,
+				Cause:             err,
+			}
+		}
+		return ev, nil
+	}
+}
+
+// NoTurnDetection is a value for RealtimeSession.TurnDetection that disables voice
+// activity detection, so that the client decides when the user has stopped talking.
+var NoTurnDetection = json.RawMessage("null")
+
+// RealtimeSession is the configuration of a Realtime session.
+type RealtimeSession struct {
+	// ID and Model are set by the server.
+	ID    string `json:"id,omitempty"`
+	Model string `json:"model,omitempty"`
+
+	Modalities   []string `json:"modalities,omitempty"` // "text" and/or "audio"
+	Instructions string   `json:"instructions,omitempty"`
+	Voice        string   `json:"voice,omitempty"` // e.g. VoiceAlloy
+
+	// Audio formats are "pcm16" (the default), "g711_ulaw" or "g711_alaw".
+	InputAudioFormat  string `json:"input_audio_format,omitempty"`
+	OutputAudioFormat string `json:"output_audio_format,omitempty"`
+
+	// InputAudioTranscription enables transcripts of user audio, e.g. with ModelWhisper1.
+	InputAudioTranscription *RealtimeTranscription `json:"input_audio_transcription,omitempty"`
+
+	// TurnDetection is a *RealtimeTurnDetection, or NoTurnDetection.
+	TurnDetection any `json:"turn_detection,omitempty"`
+
+	Tools       []any   `json:"tools,omitempty"`
+	ToolChoice  any     `json:"tool_choice,omitempty"`
+	Temperature float64 `json:"temperature,omitempty"`
+
+	// MaxResponseOutputTokens is a number or "inf".
+	MaxResponseOutputTokens any `json:"max_response_output_tokens,omitempty"`
+}
+
+type RealtimeTranscription struct {
+	Model string `json:"model"`
+}
+
+// RealtimeTurnDetection configures server voice activity detection.
+type RealtimeTurnDetection struct {
+	Type              string  `json:"type"` // "server_vad"
+	Threshold         float64 `json:"threshold,omitempty"`
+	PrefixPaddingMs   int     `json:"prefix_padding_ms,omitempty"`
+	SilenceDurationMs int     `json:"silence_duration_ms,omitempty"`
+}
+
+// RealtimeItem is a conversation item: a message, a function call or a function call output.
+type RealtimeItem struct {
+	ID     string `json:"id,omitempty"`
+	Type   string `json:"type"` // ItemMessage, ItemFunctionCall or ItemFunctionCallOutput
+	Status string `json:"status,omitempty"`
+
+	Role    Role              `json:"role,omitempty"`
+	Content []RealtimeContent `json:"content,omitempty"`
+
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+// RealtimeContent is a part of a RealtimeItem message.
+type RealtimeContent struct {
+	Type       string `json:"type"` // "input_text", "input_audio", "text" or "audio"
+	Text       string `json:"text,omitempty"`
+	Audio      string `json:"audio,omitempty"` // base64-encoded
+	Transcript string `json:"transcript,omitempty"`
+}
+
+// RealtimeTextItem makes a user text message item.
+func RealtimeTextItem(text string) RealtimeItem {
+	return RealtimeItem{Type: ItemMessage, Role: User, Content: []RealtimeContent{{Type: "input_text", Text: text}}}
+}
+
+// Types of some RealtimeEvent; see the Realtime API reference for the full list.
+const (
+	RealtimeEventError                = "error"
+	RealtimeEventSessionCreated       = "session.created"
+	RealtimeEventSessionUpdated       = "session.updated"
+	RealtimeEventItemCreated          = "conversation.item.created"
+	RealtimeEventInputTranscriptDone  = "conversation.item.input_audio_transcription.completed"
+	RealtimeEventSpeechStarted        = "input_audio_buffer.speech_started"
+	RealtimeEventSpeechStopped        = "input_audio_buffer.speech_stopped"
+	RealtimeEventAudioCommitted       = "input_audio_buffer.committed"
+	RealtimeEventResponseCreated      = "response.created"
+	RealtimeEventResponseDone         = "response.done"
+	RealtimeEventTextDelta            = "response.text.delta"
+	RealtimeEventAudioDelta           = "response.audio.delta"
+	RealtimeEventAudioDone            = "response.audio.done"
+	RealtimeEventAudioTranscriptDelta = "response.audio_transcript.delta"
+	RealtimeEventFunctionCallArgsDone = "response.function_call_arguments.done"
+	RealtimeEventRateLimitsUpdated    = "rate_limits.updated"
+)
+
+// RealtimeEvent is a server event. Only the fields relevant to the event Type are set.
+type RealtimeEvent struct {
+	Type    string `json:"type"`
+	EventID string `json:"event_id"`
+
+	Session  *RealtimeSession  `json:"session,omitempty"`
+	Item     *RealtimeItem     `json:"item,omitempty"`
+	Response *RealtimeResponse `json:"response,omitempty"`
+
+	ItemID         string `json:"item_id,omitempty"`
+	PreviousItemID string `json:"previous_item_id,omitempty"`
+	ResponseID     string `json:"response_id,omitempty"`
+	OutputIndex    int    `json:"output_index"`
+	ContentIndex   int    `json:"content_index"`
+
+	// Delta is new text, transcript, function arguments or base64-encoded audio
+	// (see AudioDelta), depending on the event.
+	Delta string `json:"delta,omitempty"`
+
+	Text       string `json:"text,omitempty"`
+	Transcript string `json:"transcript,omitempty"`
+	CallID     string `json:"call_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Arguments  string `json:"arguments,omitempty"`
+
+	AudioStartMs int `json:"audio_start_ms,omitempty"`
+	AudioEndMs   int `json:"audio_end_ms,omitempty"`
+
+	Error *RealtimeError `json:"error,omitempty"`
+
+	// Raw is the complete JSON of the event.
+	Raw json.RawMessage `json:"-"`
+}
+
+// AudioDelta decodes the audio chunk of a RealtimeEventAudioDelta event.
+func (ev *RealtimeEvent) AudioDelta() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(ev.Delta)
+}
+
+// RealtimeError is an error reported by the server. The session stays open.
+type RealtimeError struct {
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param"`
+	EventID string `json:"event_id"` // of the client event that caused the error
+}
+
+func (e *RealtimeError) Error() string {
+	return "realtime: " + e.Type + ": " + e.Message
+}
+
+// RealtimeResponse is a model response, complete in RealtimeEventResponseDone.
+type RealtimeResponse struct {
+	ID     string         `json:"id"`
+	Status string         `json:"status"` // "in_progress", "completed", "cancelled", "failed" or "incomplete"
+	Output []RealtimeItem `json:"output"`
+	Usage  *RealtimeUsage `json:"usage"`
+}
+
+// RealtimeUsage is the token usage of a RealtimeResponse; see ChatUsage for a Usage.
+type RealtimeUsage struct {
+	TotalTokens       int `json:"total_tokens"`
+	InputTokens       int `json:"input_tokens"`
+	OutputTokens      int `json:"output_tokens"`
+	InputTokenDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+		TextTokens   int `json:"text_tokens"`
+		AudioTokens  int `json:"audio_tokens"`
+	} `json:"input_token_details"`
+	OutputTokenDetails struct {
+		TextTokens  int `json:"text_tokens"`
+		AudioTokens int `json:"audio_tokens"`
+	} `json:"output_token_details"`
+}
+
+// ChatUsage converts the usage into Usage for UsageCost and CostTracker.
+func (u RealtimeUsage) ChatUsage() Usage {
+	return Usage{
+		PromptTokens:            u.InputTokens,
+		CompletionTokens:        u.OutputTokens,
+		TotalTokens:             u.TotalTokens,
+		PromptTokensDetails:     PromptTokensDetails{CachedTokens: u.InputTokenDetails.CachedTokens, AudioTokens: u.InputTokenDetails.AudioTokens},
+		CompletionTokensDetails: CompletionTokensDetails{AudioTokens: u.OutputTokenDetails.AudioTokens},
+	}
+}
+
+type realtimeClientEvent struct {
+	Type    string           `json:"type"`
+	Session *RealtimeSession `json:"session,omitempty"`
+	Item    *RealtimeItem    `json:"item,omitempty"`
+	Audio   string           `json:"audio,omitempty"`
+}
@@ -0,0 +1,162 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// serveWebSocket upgrades the request into a server-side wsConn.
+func serveWebSocket(t *testing.T, w http.ResponseWriter, r *http.Request) *wsConn {
+	if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		http.Error(w, "not a websocket request", http.StatusBadRequest)
+		return nil
+	}
+	conn, bufrw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Errorf("** Hijack: %v", err)
+		return nil
+	}
+	bufrw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	bufrw.WriteString("Sec-WebSocket-Accept: " + wsAcceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+	bufrw.Flush()
+	return newWSConn(conn, bufrw.Reader, false)
+}
+
+func TestRealtime(t *testing.T) {
+	audio := bytes.Repeat([]byte{1, 2, 3}, 20000) // large enough for 64-bit frame lengths once base64-encoded
+	serverDone := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		defer close(serverDone)
+		if r.URL.Path != "/v1/realtime" || r.URL.Query().Get("model") != ModelRealtime || r.Header.Get("OpenAI-Beta") != "realtime=v1" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		ws := serveWebSocket(t, w, r)
+		if ws == nil {
+			return
+		}
+		send := func(s string) {
+			if err := ws.WriteMessage(wsText, []byte(s)); err != nil {
+				t.Errorf("** server send: %v", err)
+			}
+		}
+		receive := func() map[string]any {
+			opcode, data, err := ws.ReadMessage()
+			if err != nil || opcode != wsText {
+				t.Errorf("** server receive = %d, %v", opcode, err)
+				return nil
+			}
+			var ev map[string]any
+			if err := json.Unmarshal(data, &ev); err != nil {
+				t.Errorf("** server receive %q: %v", data, err)
+			}
+			return ev
+		}
+
+		send(`{"type": "session.created", "event_id": "ev_1", "session": {"id": "sess_1", "model": "gpt-4o-realtime-preview", "voice": "alloy"}}`)
+
+		ev := receive()
+		if s, _ := ev["session"].(map[string]any); ev["type"] != "session.update" || s["instructions"] != "Be nice" || s["turn_detection"] != nil || len(s) != 2 {
+			t.Errorf("** session.update = %v", ev)
+		}
+		send(`{"type": "session.updated", "session": {"id": "sess_1", "instructions": "Be nice"}}`)
+
+		ev = receive()
+		if ev["type"] != "input_audio_buffer.append" || ev["audio"] != base64.StdEncoding.EncodeToString(audio) {
+			t.Errorf("** input_audio_buffer.append has wrong audio")
+		}
+		if ev = receive(); ev["type"] != "conversation.item.create" {
+			t.Errorf("** conversation.item.create = %v", ev)
+		}
+		if ev = receive(); ev["type"] != "response.create" {
+			t.Errorf("** response.create = %v", ev)
+		}
+
+		ws.writeFrame(wsPing, []byte("hi"))
+		// a text message fragmented into two frames
+		frame := func(b0 byte, payload string) []byte {
+			return append([]byte{b0, byte(len(payload))}, payload...)
+		}
+		ws.rwc.Write(frame(wsText, `{"type": "response.text.delta", "delta": `))
+		ws.rwc.Write(frame(0x80|wsContinuation, `"Hello"}`))
+		send(`{"type": "response.audio.delta", "delta": "AQID"}`)
+		send(`{"type": "response.done", "response": {"id": "resp_1", "status": "completed", "usage": {"total_tokens": 30, "input_tokens": 20, "output_tokens": 10, "input_token_details": {"cached_tokens": 0, "text_tokens": 5, "audio_tokens": 15}, "output_token_details": {"text_tokens": 2, "audio_tokens": 8}}}}`)
+		send(`{"type": "error", "error": {"type": "invalid_request_error", "code": "invalid_value", "message": "Bad voice"}}`)
+
+		if fin, op, payload, err := ws.readFrame(); err != nil || !fin || op != wsPong || string(payload) != "hi" {
+			t.Errorf("** pong = %v, %d, %q, %v", fin, op, payload, err)
+		}
+		ws.Close(wsCloseNormal, "bye")
+	})
+	ctx := context.Background()
+
+	c, err := ConnectRealtime(ctx, ModelRealtime, client, Credentials{APIKey: "sk-test"})
+	if err != nil {
+		t.Fatalf("** ConnectRealtime: %v", err)
+	}
+	defer c.Close()
+
+	receive := func(typ string) *RealtimeEvent {
+		t.Helper()
+		ev, err := c.Receive()
+		if err != nil {
+			t.Fatalf("** Receive: %v", err)
+		}
+		if ev.Type != typ {
+			t.Fatalf("** Receive = %s, wanted %s: %s", ev.Type, typ, ev.Raw)
+		}
+		return ev
+	}
+
+	if ev := receive(RealtimeEventSessionCreated); ev.Session.ID != "sess_1" || ev.EventID != "ev_1" {
+		t.Errorf("** session.created = %+v", ev.Session)
+	}
+	ensure(c.UpdateSession(RealtimeSession{Instructions: "Be nice", TurnDetection: NoTurnDetection}))
+	if ev := receive(RealtimeEventSessionUpdated); ev.Session.Instructions != "Be nice" {
+		t.Errorf("** session.updated = %+v", ev.Session)
+	}
+
+	ensure(c.AppendAudio(audio))
+	ensure(c.CreateItem(RealtimeTextItem("Hi")))
+	ensure(c.CreateResponse())
+
+	if ev := receive(RealtimeEventTextDelta); ev.Delta != "Hello" {
+		t.Errorf("** text delta = %q", ev.Delta)
+	}
+	if ev := receive(RealtimeEventAudioDelta); !bytes.Equal(must(ev.AudioDelta()), []byte{1, 2, 3}) {
+		t.Errorf("** audio delta = %q", ev.Delta)
+	}
+	ev := receive(RealtimeEventResponseDone)
+	if u := ev.Response.Usage.ChatUsage(); u.PromptTokensDetails.AudioTokens != 15 || u.CompletionTokensDetails.AudioTokens != 8 || u.TotalTokens != 30 {
+		t.Errorf("** usage = %+v", u)
+	}
+	if ev := receive(RealtimeEventError); ev.Error == nil || ev.Error.Code != "invalid_value" || !strings.Contains(ev.Error.Error(), "Bad voice") {
+		t.Errorf("** error = %+v", ev.Error)
+	}
+
+	_, err = c.Receive()
+	var ce *WebSocketCloseError
+	if !errors.As(err, &ce) || ce.Code != wsCloseNormal || ce.Reason != "bye" {
+		t.Errorf("** Receive after close: %v", err)
+	}
+	<-serverDone
+}
+
+func TestConnectRealtimeError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": {"message": "Incorrect API key", "type": "invalid_request_error"}}`))
+	})
+	_, err := ConnectRealtime(context.Background(), ModelRealtime, client, Credentials{})
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != 401 {
+		t.Errorf("** ConnectRealtime = %v", err)
+	}
+}
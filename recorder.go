@@ -0,0 +1,420 @@
+package openai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// RecorderMode says whether a Recorder records or replays API calls.
+type RecorderMode int
+
+const (
+	// Replay serves calls from fixture files and fails calls that have not been recorded.
+	Replay RecorderMode = iota
+
+	// Record sends calls to the API and saves them into fixture files, replacing
+	// fixtures recorded before.
+	Record
+)
+
+// Recorder is an http.RoundTripper that records API calls into fixture files and
+// replays them later, so that tests can run offline and deterministically:
+//
+//	mode := openai.Replay
+//	if os.Getenv("OPENAI_RECORD") != "" {
+//		mode = openai.Record
+//	}
+//	client := &http.Client{Transport: &openai.Recorder{Dir: "testdata/openai", Mode: mode}}
+//
+// Requests are matched by method, URL and body; JSON bodies are normalized, so formatting
+// and key order don't matter. Identical requests made repeatedly (e.g. when polling)
+// are recorded as a sequence and replayed in the same order, with the last response
+// repeating. Streaming responses are recorded event by event, along with their timing.
+//
+// Credentials and request headers are never saved. The zero value replays fixtures from
+// the current directory. Safe for concurrent use.
+type Recorder struct {
+	// Dir is the directory with fixture files.
+	Dir string
+
+	Mode RecorderMode
+
+	// Transport sends calls when recording; defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// KeepTiming makes replayed streaming responses arrive with recorded delays
+	// between chunks, instead of all at once.
+	KeepTiming bool
+
+	mut      sync.Mutex
+	calls    map[string]int
+	recorded map[string][]*recording
+	loaded   map[string][]*recording
+}
+
+// recording is one call saved in a fixture file. Each file holds a JSON array of
+// recordings of the same request.
+type recording struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"request_body,omitempty"`
+	StatusCode  int         `json:"status_code"`
+	Header      http.Header `json:"header,omitempty"`
+
+	// Body is the response body, or empty if Chunks or BodyBase64 are set.
+	Body string `json:"body,omitempty"`
+
+	// BodyBase64 is the response body if it isn't valid UTF-8, e.g. audio.
+	BodyBase64 string `json:"body_base64,omitempty"`
+
+	// Chunks are the events of a streaming response.
+	Chunks []recordedChunk `json:"chunks,omitempty"`
+}
+
+type recordedChunk struct {
+	// DelayMs is the time since the previous chunk or, for the first chunk, since the response headers.
+	DelayMs int64  `json:"delay_ms"`
+	Data    string `json:"data"`
+}
+
+// unrecordedHeaders are response headers not worth saving.
+var unrecordedHeaders = []string{"Date", "Set-Cookie", "Content-Length"}
+
+func (rec *Recorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	normBody := normalizeRequestBody(r.Header.Get("Content-Type"), body)
+	key := fixtureKey(r.Method, r.URL.String(), normBody)
+
+	rec.mut.Lock()
+	if rec.calls == nil {
+		rec.calls = make(map[string]int)
+	}
+	seq := rec.calls[key]
+	rec.calls[key]++
+	rec.mut.Unlock()
+
+	if rec.Mode == Record {
+		return rec.record(r, body, key, seq, normBody)
+	}
+	return rec.replay(r, key, seq)
+}
+
+func (rec *Recorder) record(r *http.Request, body []byte, key string, seq int, normBody string) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	transport := rec.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &recording{
+		Method:      r.Method,
+		URL:         r.URL.String(),
+		RequestBody: normBody,
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header.Clone(),
+	}
+	for _, k := range unrecordedHeaders {
+		rc.Header.Del(k)
+	}
+	ctype, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	resp.Body = &recordingBody{
+		body:      resp.Body,
+		streaming: ctype == eventStreamContentType,
+		last:      time.Now(),
+		rc:        rc,
+		save: func(rc *recording) error {
+			return rec.save(key, seq, rc)
+		},
+	}
+	return resp, nil
+}
+
+func (rec *Recorder) save(key string, seq int, rc *recording) error {
+	rec.mut.Lock()
+	defer rec.mut.Unlock()
+	if rec.recorded == nil {
+		rec.recorded = make(map[string][]*recording)
+	}
+	list := rec.recorded[key]
+	for len(list) <= seq {
+		list = append(list, nil)
+	}
+	list[seq] = rc
+	rec.recorded[key] = list
+
+	var done []*recording
+	for _, rc := range list {
+		if rc != nil {
+			done = append(done, rc)
+		}
+	}
+	data, err := json.MarshalIndent(done, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(rec.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rec.Dir, key+".json"), append(data, '\n'), 0o644)
+}
+
+func (rec *Recorder) replay(r *http.Request, key string, seq int) (*http.Response, error) {
+	rec.mut.Lock()
+	list, ok := rec.loaded[key]
+	rec.mut.Unlock()
+	if !ok {
+		fn := filepath.Join(rec.Dir, key+".json")
+		data, err := os.ReadFile(fn)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no recorded response for %s %s (%s)", r.Method, r.URL, fn)
+		} else if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("%s: no recordings", fn)
+		}
+		rec.mut.Lock()
+		if rec.loaded == nil {
+			rec.loaded = make(map[string][]*recording)
+		}
+		rec.loaded[key] = list
+		rec.mut.Unlock()
+	}
+	if seq >= len(list) {
+		seq = len(list) - 1
+	}
+	rc := list[seq]
+
+	chunks := append([]recordedChunk(nil), rc.Chunks...) // replayBody consumes them
+	if rc.Chunks == nil {
+		body := rc.Body
+		if rc.BodyBase64 != "" {
+			raw, err := base64.StdEncoding.DecodeString(rc.BodyBase64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: body_base64: %w", filepath.Join(rec.Dir, key+".json"), err)
+			}
+			body = string(raw)
+		}
+		chunks = []recordedChunk{{Data: body}}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rc.StatusCode, http.StatusText(rc.StatusCode)),
+		StatusCode:    rc.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rc.Header.Clone(),
+		Body:          &replayBody{r: r, chunks: chunks, keepTiming: rec.KeepTiming},
+		ContentLength: -1,
+		Request:       r,
+	}, nil
+}
+
+// normalizeRequestBody makes equivalent request bodies equal: JSON is re-encoded
+// with sorted keys, and random multipart boundaries are replaced with a fixed one.
+func normalizeRequestBody(contentType string, body []byte) string {
+	ctype, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case ctype == "application/json":
+		var v any
+		d := json.NewDecoder(bytes.NewReader(body))
+		d.UseNumber()
+		if d.Decode(&v) == nil {
+			return strings.TrimSpace(string(saneMarshal(v)))
+		}
+	case strings.HasPrefix(ctype, "multipart/") && params["boundary"] != "":
+		return strings.ReplaceAll(string(body), params["boundary"], "BOUNDARY")
+	}
+	return string(body)
+}
+
+// fixtureKey returns the fixture file name (sans extension) for a request,
+// e.g. "v1-chat-completions-1b4f0e98".
+func fixtureKey(method, url, normBody string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n%s", method, url, normBody)
+	hash := hex.EncodeToString(h.Sum(nil))[:8]
+
+	path := url
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+3:]
+		if j := strings.IndexByte(path, '/'); j >= 0 {
+			path = path[j:]
+		}
+	}
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	var buf strings.Builder
+	for _, c := range strings.Trim(path, "/") {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' {
+			buf.WriteRune(c)
+		} else {
+			buf.WriteByte('-')
+		}
+	}
+	buf.WriteByte('-')
+	buf.WriteString(hash)
+	return buf.String()
+}
+
+// recordingBody passes the response body through, saving the recording once
+// the body has been read to the end or closed.
+type recordingBody struct {
+	body      io.ReadCloser
+	streaming bool
+	last      time.Time
+	rc        *recording
+	pending   []byte
+	save      func(rc *recording) error
+	saved     bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.add(p[:n])
+	if err == io.EOF {
+		if serr := b.finish(); serr != nil {
+			return n, serr
+		}
+	}
+	return n, err
+}
+
+func (b *recordingBody) add(data []byte) {
+	b.pending = append(b.pending, data...)
+	if !b.streaming {
+		return
+	}
+	// save each complete event as a chunk
+	for {
+		i := bytes.Index(b.pending, []byte("\n\n"))
+		if i < 0 {
+			break
+		}
+		b.addChunk(b.pending[:i+2])
+		b.pending = b.pending[i+2:]
+	}
+}
+
+func (b *recordingBody) addChunk(data []byte) {
+	now := time.Now()
+	b.rc.Chunks = append(b.rc.Chunks, recordedChunk{
+		DelayMs: now.Sub(b.last).Milliseconds(),
+		Data:    string(data),
+	})
+	b.last = now
+}
+
+func (b *recordingBody) finish() error {
+	if b.saved {
+		return nil
+	}
+	b.saved = true
+	if b.streaming {
+		if len(b.pending) > 0 {
+			b.addChunk(b.pending)
+		}
+	} else if utf8.Valid(b.pending) {
+		b.rc.Body = string(b.pending)
+	} else {
+		b.rc.BodyBase64 = base64.StdEncoding.EncodeToString(b.pending)
+	}
+	b.pending = nil
+	return b.save(b.rc)
+}
+
+// Close reads the rest of the body, so that the recording is complete even if
+// the caller stopped early (e.g. at the end marker of a stream).
+func (b *recordingBody) Close() error {
+	var err error
+	if !b.saved {
+		buf := make([]byte, 4096)
+		for err == nil {
+			_, err = b.Read(buf)
+		}
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if cerr := b.body.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+type replayBody struct {
+	r          *http.Request
+	chunks     []recordedChunk
+	keepTiming bool
+	started    bool
+	closed     bool
+}
+
+func (b *replayBody) Read(p []byte) (int, error) {
+	if b.closed {
+		return 0, errors.New("read on closed response body")
+	}
+	for len(b.chunks) > 0 {
+		c := &b.chunks[0]
+		if !b.started {
+			b.started = true
+			if b.keepTiming && c.DelayMs > 0 {
+				t := time.NewTimer(time.Duration(c.DelayMs) * time.Millisecond)
+				select {
+				case <-t.C:
+				case <-b.r.Context().Done():
+					t.Stop()
+					return 0, b.r.Context().Err()
+				}
+			}
+		}
+		if c.Data == "" {
+			b.chunks = b.chunks[1:]
+			b.started = false
+			continue
+		}
+		n := copy(p, c.Data)
+		c.Data = c.Data[n:]
+		return n, nil
+	}
+	return 0, io.EOF
+}
+
+func (b *replayBody) Close() error {
+	b.closed = true
+	return nil
+}
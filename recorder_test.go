@@ -0,0 +1,208 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureClient replays API calls recorded in testdata/fixtures. To re-record them
+// against the real API, run tests with OPENAI_RECORD=1 and OPENAI_API_KEY set.
+func fixtureClient() (*http.Client, Credentials) {
+	rec := &Recorder{Dir: "testdata/fixtures"}
+	var creds Credentials
+	if os.Getenv("OPENAI_RECORD") != "" {
+		rec.Mode = Record
+		creds.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return &http.Client{Transport: rec}, creds
+}
+
+func TestChat(t *testing.T) {
+	client, creds := fixtureClient()
+	opt := DefaultChatOptions()
+	opt.Model = ModelChatGPT4oMini
+	msgs, usage, err := Chat(context.Background(), []Msg{SystemMsg("Answer with one word."), UserMsg("What color is the sky?")}, opt, client, creds)
+	if err != nil {
+		t.Fatalf("** Chat: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Role != Assistant || msgs[0].Content != "Blue." {
+		t.Errorf("** Chat = %+v", msgs)
+	}
+	if usage.PromptTokens != 23 || usage.CompletionTokens != 2 || usage.TotalTokens != 25 {
+		t.Errorf("** usage = %+v", usage)
+	}
+}
+
+func TestStreamChat(t *testing.T) {
+	client, creds := fixtureClient()
+	opt := DefaultChatOptions()
+	opt.Model = ModelChatGPT4oMini
+	var deltas []string
+	msg, err := StreamChat(context.Background(), []Msg{UserMsg("Count to three.")}, opt, client, creds, func(msg *Msg, delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("** StreamChat: %v", err)
+	}
+	if msg.Role != Assistant || msg.Content != "One, two, three." {
+		t.Errorf("** StreamChat = %+v", msg)
+	}
+	expected := []string{"", "One", ",", " two", ",", " three", ".", ""}
+	if !reflect.DeepEqual(deltas, expected) {
+		t.Errorf("** deltas = %q, wanted %q", deltas, expected)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var polls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			var req chatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if !req.Stream {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "Hi"}}], "usage": {"prompt_tokens": 5, "completion_tokens": 1, "total_tokens": 6}}`))
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, s := range []string{`{"choices": [{"delta": {"role": "assistant"}}]}`, `{"choices": [{"delta": {"content": "Hi"}}]}`, `[DONE]`} {
+				time.Sleep(20 * time.Millisecond)
+				w.Write([]byte("data: " + s + "\n\n"))
+				w.(http.Flusher).Flush()
+			}
+		case "/v1/threads/thread_1/runs/run_1":
+			polls++
+			status := RunInProgress
+			if polls > 1 {
+				status = RunCompleted
+			}
+			w.Write([]byte(`{"id": "run_1", "status": "` + status + `"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	exercise := func(client *http.Client) {
+		t.Helper()
+		msgs, _, err := Chat(ctx, []Msg{UserMsg("Hello")}, DefaultChatOptions(), client, Credentials{APIKey: "sk-secret"})
+		if err != nil || msgs[0].Content != "Hi" {
+			t.Errorf("** Chat = %+v, %v", msgs, err)
+		}
+		msg, err := StreamChat(ctx, []Msg{UserMsg("Hello")}, DefaultChatOptions(), client, Credentials{APIKey: "sk-secret"}, func(msg *Msg, delta string) error {
+			return nil
+		})
+		if err != nil || msg.Content != "Hi" {
+			t.Errorf("** StreamChat = %+v, %v", msg, err)
+		}
+		var statuses []RunStatus
+		for i := 0; i < 3; i++ {
+			run, err := RetrieveRun(ctx, "thread_1", "run_1", client, Credentials{})
+			if err != nil {
+				t.Fatalf("** RetrieveRun: %v", err)
+			}
+			statuses = append(statuses, run.Status)
+		}
+		if expected := []RunStatus{RunInProgress, RunCompleted, RunCompleted}; !reflect.DeepEqual(statuses, expected) {
+			t.Errorf("** statuses = %v, wanted %v", statuses, expected)
+		}
+	}
+
+	rec := &Recorder{Dir: dir, Mode: Record, Transport: &redirectTransport{must(url.Parse(srv.URL))}}
+	exercise(&http.Client{Transport: rec})
+
+	files := must(filepath.Glob(filepath.Join(dir, "*.json")))
+	if len(files) != 3 {
+		t.Fatalf("** recorded %v", files)
+	}
+	for _, fn := range files {
+		data := string(must(os.ReadFile(fn)))
+		if strings.Contains(data, "sk-secret") {
+			t.Errorf("** %s contains the API key", fn)
+		}
+		var list []*recording
+		ensure(json.Unmarshal([]byte(data), &list))
+		switch base := filepath.Base(fn); {
+		case strings.HasPrefix(base, "v1-threads-thread_1-runs-run_1-"):
+			if len(list) != 3 {
+				t.Errorf("** %s has %d recordings, wanted 3", base, len(list))
+			}
+		case strings.Contains(data, `"chunks"`):
+			if len(list) != 1 || len(list[0].Chunks) != 3 || list[0].Chunks[1].DelayMs < 10 || list[0].Chunks[2].Data != "data: [DONE]\n\n" {
+				t.Errorf("** %s = %s", base, data)
+			}
+		}
+	}
+
+	srv.Close()
+	exercise(&http.Client{Transport: &Recorder{Dir: dir}})
+
+	start := time.Now()
+	_, err := StreamChat(ctx, []Msg{UserMsg("Hello")}, DefaultChatOptions(), &http.Client{Transport: &Recorder{Dir: dir, KeepTiming: true}}, Credentials{}, func(msg *Msg, delta string) error {
+		return nil
+	})
+	if elapsed := time.Since(start); err != nil || elapsed < 40*time.Millisecond {
+		t.Errorf("** StreamChat with KeepTiming took %v: %v", elapsed, err)
+	}
+
+	_, _, err = Chat(ctx, []Msg{UserMsg("Bye")}, DefaultChatOptions(), &http.Client{Transport: &Recorder{Dir: dir}}, Credentials{})
+	var e *Error
+	if !errors.As(err, &e) || !e.IsNetwork || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("** unrecorded call: %v", err)
+	}
+}
+
+func TestRecorderBinary(t *testing.T) {
+	audio := []byte{'I', 'D', '3', 0x04, 0x00, 0xff, 0xfb, 0x90, 0x64, 0x00, 0xc3, 0x28}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write(audio)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	rec := &Recorder{Dir: dir, Mode: Record, Transport: &redirectTransport{must(url.Parse(srv.URL))}}
+	var buf bytes.Buffer
+	ensure(Speech(ctx, "Hello", SpeechOptions{}, &buf, &http.Client{Transport: rec}, Credentials{}))
+	if !bytes.Equal(buf.Bytes(), audio) {
+		t.Fatalf("** recorded Speech = %x", buf.Bytes())
+	}
+	files := must(filepath.Glob(filepath.Join(dir, "*.json")))
+	if len(files) != 1 || !strings.Contains(string(must(os.ReadFile(files[0]))), `"body_base64"`) {
+		t.Errorf("** recorded %v", files)
+	}
+
+	srv.Close()
+	buf.Reset()
+	ensure(Speech(ctx, "Hello", SpeechOptions{}, &buf, &http.Client{Transport: &Recorder{Dir: dir}}, Credentials{}))
+	if !bytes.Equal(buf.Bytes(), audio) {
+		t.Errorf("** replayed Speech = %x, wanted %x", buf.Bytes(), audio)
+	}
+}
+
+func TestNormalizeRequestBody(t *testing.T) {
+	a := normalizeRequestBody("application/json", []byte(`{"b": 1.50, "a": [true, "<x>"]}`))
+	b := normalizeRequestBody("application/json; charset=utf-8", []byte(`{"a":[true,"<x>"],"b":1.50}`+"\n"))
+	if a != b || a != `{"a":[true,"<x>"],"b":1.50}` {
+		t.Errorf("** normalized %q and %q", a, b)
+	}
+	if s := normalizeRequestBody("multipart/form-data; boundary=xyz", []byte("--xyz\r\n--xyz--")); s != "--BOUNDARY\r\n--BOUNDARY--" {
+		t.Errorf("** normalized multipart = %q", s)
+	}
+}
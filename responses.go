@@ -0,0 +1,457 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ResponseOptions control CreateResponse and StreamResponse.
+type ResponseOptions struct {
+	Model string `json:"model"`
+
+	// Instructions are a system (developer) message inserted before the input.
+	// Unlike input messages, they are not carried over via PreviousResponseID.
+	Instructions string `json:"instructions,omitempty"`
+
+	// PreviousResponseID continues a stored conversation, so that only the new
+	// input needs to be sent.
+	PreviousResponseID string `json:"previous_response_id,omitempty"`
+
+	// Store controls whether the response is stored for retrieval and continuation;
+	// nil means the API default, which is to store.
+	Store *bool `json:"store,omitempty"`
+
+	// Tools are built-in tools like map[string]any{"type": "web_search_preview"},
+	// or functions defined via ResponseFunctionTool.
+	Tools      []any `json:"tools,omitempty"`
+	ToolChoice any   `json:"tool_choice,omitempty"` // "auto", "required", "none" or a specific tool
+
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+
+	// Reasoning configures reasoning models like ModelO1.
+	Reasoning *ReasoningOptions `json:"reasoning,omitempty"`
+
+	// MaxOutputTokens limits output, including reasoning tokens; 0 means no limit.
+	MaxOutputTokens int `json:"max_output_tokens,omitempty"`
+
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+
+	// Include requests additional output data, e.g. "reasoning.encrypted_content"
+	// to pass reasoning items between calls without storing them.
+	Include []string `json:"include,omitempty"`
+
+	// Truncation is "auto" to drop items from the middle of the conversation if it
+	// exceeds the context window, or "disabled" (the default) to fail instead.
+	Truncation string `json:"truncation,omitempty"`
+
+	Metadata map[string]string `json:"metadata,omitempty"`
+	User     string            `json:"user,omitempty"`
+}
+
+// ReasoningOptions configure reasoning models.
+type ReasoningOptions struct {
+	Effort  string `json:"effort,omitempty"`  // "low", "medium" or "high"
+	Summary string `json:"summary,omitempty"` // "auto", "concise" or "detailed"
+}
+
+// ResponseFunctionTool defines a function the model can call via ResponseOptions.Tools.
+type ResponseFunctionTool struct {
+	Type        string `json:"type"` // always "function"
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters"` // JSON schema
+	Strict      bool   `json:"strict"`
+}
+
+// FunctionTool makes a ResponseFunctionTool.
+func FunctionTool(name, description string, parameters any) ResponseFunctionTool {
+	return ResponseFunctionTool{Type: "function", Name: name, Description: description, Parameters: parameters}
+}
+
+// Types of ResponseItem.
+const (
+	ItemMessage            = "message"
+	ItemFunctionCall       = "function_call"
+	ItemFunctionCallOutput = "function_call_output"
+	ItemReasoning          = "reasoning"
+	ItemWebSearchCall      = "web_search_call"
+	ItemFileSearchCall     = "file_search_call"
+	ItemReference          = "item_reference"
+)
+
+// Types of ResponseContent.
+const (
+	ContentInputText  = "input_text"
+	ContentInputImage = "input_image"
+	ContentInputFile  = "input_file"
+	ContentOutputText = "output_text"
+	ContentRefusal    = "refusal"
+	ContentSummary    = "summary_text"
+)
+
+// ResponseItem is an input or output item of the Responses API. Only the fields
+// relevant to the item Type are set.
+type ResponseItem struct {
+	Type   string `json:"type"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status,omitempty"`
+
+	// ItemMessage
+	Role    Role              `json:"role,omitempty"`
+	Content []ResponseContent `json:"content,omitempty"`
+
+	// ItemFunctionCall and ItemFunctionCallOutput
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+
+	// ItemReasoning
+	Summary          []ResponseContent `json:"summary,omitempty"`
+	EncryptedContent string            `json:"encrypted_content,omitempty"`
+
+	// ItemFileSearchCall
+	Queries []string `json:"queries,omitempty"`
+	Results any      `json:"results,omitempty"`
+}
+
+// ResponseContent is a part of a message or a reasoning summary.
+type ResponseContent struct {
+	Type string `json:"type"`
+
+	Text    string `json:"text,omitempty"`
+	Refusal string `json:"refusal,omitempty"`
+
+	// ContentInputImage and ContentInputFile
+	ImageURL string `json:"image_url,omitempty"`
+	FileID   string `json:"file_id,omitempty"`
+	Detail   string `json:"detail,omitempty"` // "low", "high" or "auto"
+
+	// Annotations cite sources of ContentOutputText, e.g. web search results.
+	Annotations []ResponseAnnotation `json:"annotations,omitempty"`
+}
+
+// ResponseAnnotation is a citation within output text.
+type ResponseAnnotation struct {
+	Type       string `json:"type"` // "url_citation", "file_citation"
+	URL        string `json:"url,omitempty"`
+	Title      string `json:"title,omitempty"`
+	FileID     string `json:"file_id,omitempty"`
+	StartIndex int    `json:"start_index,omitempty"`
+	EndIndex   int    `json:"end_index,omitempty"`
+	Index      int    `json:"index,omitempty"`
+}
+
+// InputText makes an input message with the given role and text.
+func InputText(role Role, text string) ResponseItem {
+	return ResponseItem{Type: ItemMessage, Role: role, Content: []ResponseContent{{Type: ContentInputText, Text: text}}}
+}
+
+// FunctionCallOutput makes an item returning the result of a function call to the model.
+func FunctionCallOutput(callID, output string) ResponseItem {
+	return ResponseItem{Type: ItemFunctionCallOutput, CallID: callID, Output: output}
+}
+
+// ResponseInput converts chat messages into Responses API input. Chat function calls
+// carry no call IDs, so IDs are synthesized, and each Function message answers
+// the latest preceding call of the function with the same name.
+func ResponseInput(msgs []Msg) []ResponseItem {
+	items := make([]ResponseItem, 0, len(msgs))
+	callIDs := make(map[string]string)
+	for i, msg := range msgs {
+		switch msg.Role {
+		case Assistant:
+			if msg.Content != "" {
+				items = append(items, ResponseItem{Type: ItemMessage, Role: Assistant, Content: []ResponseContent{{Type: ContentOutputText, Text: msg.Content}}})
+			}
+			if fc := msg.FunctionCall; fc != nil {
+				callID := "call_" + strconv.Itoa(i)
+				callIDs[fc.Name] = callID
+				items = append(items, ResponseItem{Type: ItemFunctionCall, CallID: callID, Name: fc.Name, Arguments: fc.Arguments})
+			}
+			for _, tc := range msg.ToolCalls {
+				items = append(items, ResponseItem{Type: ItemFunctionCall, CallID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+			}
+		case Function:
+			items = append(items, FunctionCallOutput(callIDs[msg.Name], msg.Content))
+		case Tool:
+			items = append(items, FunctionCallOutput(msg.ToolCallID, msg.Content))
+		default:
+			items = append(items, InputText(msg.Role, msg.Content))
+		}
+	}
+	return items
+}
+
+type ResponseStatus string
+
+const (
+	ResponseCompleted  ResponseStatus = "completed"
+	ResponseFailed     ResponseStatus = "failed"
+	ResponseInProgress ResponseStatus = "in_progress"
+	ResponseIncomplete ResponseStatus = "incomplete"
+	ResponseCancelled  ResponseStatus = "cancelled"
+	ResponseQueued     ResponseStatus = "queued"
+)
+
+// Response is the result of CreateResponse and StreamResponse.
+type Response struct {
+	ID                 string         `json:"id"`
+	CreatedAt          int64          `json:"created_at"`
+	Model              string         `json:"model"`
+	Status             ResponseStatus `json:"status"`
+	PreviousResponseID string         `json:"previous_response_id"`
+	Output             []ResponseItem `json:"output"`
+	Usage              ResponseUsage  `json:"usage"`
+	Error              *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"` // "max_output_tokens" or "content_filter"
+	} `json:"incomplete_details"`
+}
+
+// OutputText returns the concatenated text of all output messages.
+func (r *Response) OutputText() string {
+	var buf strings.Builder
+	for _, item := range r.Output {
+		if item.Type != ItemMessage {
+			continue
+		}
+		for _, c := range item.Content {
+			if c.Type == ContentOutputText {
+				buf.WriteString(c.Text)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// FunctionCalls returns the function calls the model wants to make. Reply to them
+// with FunctionCallOutput items in a follow-up call with PreviousResponseID.
+func (r *Response) FunctionCalls() []ResponseItem {
+	var result []ResponseItem
+	for _, item := range r.Output {
+		if item.Type == ItemFunctionCall {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// ResponseUsage is the token usage of a Response; see ChatUsage for a Usage.
+type ResponseUsage struct {
+	InputTokens        int `json:"input_tokens"`
+	OutputTokens       int `json:"output_tokens"`
+	TotalTokens        int `json:"total_tokens"`
+	InputTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+	OutputTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+}
+
+// ChatUsage converts the usage into Usage for UsageCost and CostTracker.
+func (u ResponseUsage) ChatUsage() Usage {
+	return Usage{
+		PromptTokens:            u.InputTokens,
+		CompletionTokens:        u.OutputTokens,
+		TotalTokens:             u.TotalTokens,
+		PromptTokensDetails:     PromptTokensDetails{CachedTokens: u.InputTokensDetails.CachedTokens},
+		CompletionTokensDetails: CompletionTokensDetails{ReasoningTokens: u.OutputTokensDetails.ReasoningTokens},
+	}
+}
+
+// Types of ResponseEvent. Other events are passed through as well.
+const (
+	ResponseEventCreated               = "response.created"
+	ResponseEventInProgress            = "response.in_progress"
+	ResponseEventCompleted             = "response.completed"
+	ResponseEventFailed                = "response.failed"
+	ResponseEventIncomplete            = "response.incomplete"
+	ResponseEventOutputItemAdded       = "response.output_item.added"
+	ResponseEventOutputItemDone        = "response.output_item.done"
+	ResponseEventOutputTextDelta       = "response.output_text.delta"
+	ResponseEventOutputTextDone        = "response.output_text.done"
+	ResponseEventFunctionArgsDelta     = "response.function_call_arguments.delta"
+	ResponseEventFunctionArgsDone      = "response.function_call_arguments.done"
+	ResponseEventReasoningSummaryDelta = "response.reasoning_summary_text.delta"
+	ResponseEventError                 = "error"
+)
+
+// ResponseEvent is a streaming event of StreamResponse. Only the fields relevant
+// to the event Type are set.
+type ResponseEvent struct {
+	// Type is the SSE event name, e.g. ResponseEventOutputTextDelta.
+	Type string `json:"type"`
+
+	// EventID is the SSE event ID, if sent.
+	EventID string `json:"-"`
+
+	SequenceNumber int `json:"sequence_number"`
+
+	// Response is set for lifecycle events like ResponseEventCompleted.
+	Response *Response `json:"response,omitempty"`
+
+	// Item is set for ResponseEventOutputItemAdded and ResponseEventOutputItemDone.
+	Item *ResponseItem `json:"item,omitempty"`
+
+	ItemID       string `json:"item_id,omitempty"`
+	OutputIndex  int    `json:"output_index"`
+	ContentIndex int    `json:"content_index"`
+
+	// Delta is the new chunk of text or function arguments.
+	Delta string `json:"delta,omitempty"`
+
+	// Text and Arguments are the complete values in the *.done events.
+	Text      string `json:"text,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+
+	// Code and Message describe ResponseEventError.
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// CreateResponse generates a model response via the Responses API.
+func CreateResponse(ctx context.Context, input []ResponseItem, opt ResponseOptions, client *http.Client, creds Credentials) (*Response, error) {
+	const callID = "CreateResponse"
+
+	tc, err := trackCall(ctx, callID, opt.Model, 1, func() (int, int) {
+		return responseInputTokenCount(input, opt), opt.MaxOutputTokens
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp Response
+	err = post(ctx, callID, "https://api.openai.com/v1/responses", client, creds, &responseRequest{input, opt, false}, &resp)
+	if err != nil {
+		tc.finish(nil)
+		return nil, err
+	}
+	usage := resp.Usage.ChatUsage()
+	tc.finish(&usage)
+	return &resp, resp.err(callID)
+}
+
+// StreamResponse is like CreateResponse, but calls f for each streaming event as it arrives.
+// Returns the final response carried by the last lifecycle event.
+func StreamResponse(ctx context.Context, input []ResponseItem, opt ResponseOptions, client *http.Client, creds Credentials, f func(ev *ResponseEvent) error) (*Response, error) {
+	const callID = "StreamResponse"
+
+	tc, err := trackCall(ctx, callID, opt.Model, 1, func() (int, int) {
+		return responseInputTokenCount(input, opt), opt.MaxOutputTokens
+	})
+	if err != nil {
+		return nil, err
+	}
+	var final *Response
+	var streamErr error
+	defer func() {
+		if final != nil {
+			usage := final.Usage.ChatUsage()
+			tc.finish(&usage)
+		} else {
+			tc.finish(nil)
+		}
+	}()
+
+	err = post(ctx, callID, "https://api.openai.com/v1/responses", client, creds, &responseRequest{input, opt, true}, func(id, event string, data []byte) error {
+		var ev ResponseEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return err
+		}
+		if event != "" {
+			ev.Type = event
+		}
+		ev.EventID = id
+		switch ev.Type {
+		case ResponseEventError:
+			streamErr = &Error{
+				CallID:  callID,
+				Type:    ev.Code,
+				Message: ev.Message,
+			}
+			return streamErr
+		case ResponseEventCompleted, ResponseEventFailed, ResponseEventIncomplete:
+			final = ev.Response
+		}
+		return f(&ev)
+	})
+	if streamErr != nil {
+		return nil, streamErr // rather than wrapped into a chunk processing error
+	} else if err != nil {
+		return nil, err
+	}
+	if final == nil {
+		return nil, &Error{
+			CallID:  callID,
+			Message: "stream ended without a final response",
+		}
+	}
+	return final, final.err(callID)
+}
+
+// RetrieveResponse returns a stored response.
+func RetrieveResponse(ctx context.Context, responseID string, client *http.Client, creds Credentials) (*Response, error) {
+	const callID = "RetrieveResponse"
+	var resp Response
+	err := get(ctx, callID, "https://api.openai.com/v1/responses/"+url.PathEscape(responseID), client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteResponse deletes a stored response.
+func DeleteResponse(ctx context.Context, responseID string, client *http.Client, creds Credentials) error {
+	const callID = "DeleteResponse"
+	var resp deleteResponse
+	err := del(ctx, callID, "https://api.openai.com/v1/responses/"+url.PathEscape(responseID), client, creds, &resp)
+	if err != nil {
+		return err
+	}
+	if !resp.Deleted {
+		return &Error{
+			CallID:  callID,
+			Message: "response was not deleted",
+		}
+	}
+	return nil
+}
+
+func (r *Response) err(callID string) error {
+	if r.Status == ResponseFailed && r.Error != nil {
+		return &Error{
+			CallID:  callID,
+			Type:    r.Error.Code,
+			Message: r.Error.Message,
+		}
+	}
+	return nil
+}
+
+type responseRequest struct {
+	Input []ResponseItem `json:"input"`
+	ResponseOptions
+	Stream bool `json:"stream,omitempty"`
+}
+
+// responseInputTokenCount approximates prompt tokens for budget enforcement.
+func responseInputTokenCount(input []ResponseItem, opt ResponseOptions) int {
+	n := TokenCount(opt.Instructions, opt.Model)
+	for _, item := range input {
+		for _, c := range item.Content {
+			n += TokenCount(c.Text, opt.Model)
+		}
+		n += TokenCount(item.Arguments, opt.Model) + TokenCount(item.Output, opt.Model)
+	}
+	return n
+}
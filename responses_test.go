@@ -0,0 +1,156 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResponseInput(t *testing.T) {
+	items := ResponseInput([]Msg{
+		SystemMsg("Be brief."),
+		UserMsg("Weather in Paris?"),
+		{Role: Assistant, FunctionCall: &FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+		{Role: Function, Name: "weather", Content: "sunny"},
+		{Role: Assistant, ToolCalls: []ToolCall{{ID: "call_a", Type: "function", Function: FunctionCall{Name: "weather", Arguments: `{"city":"Rome"}`}}}},
+		{Role: Tool, ToolCallID: "call_a", Content: "rainy"},
+		AssistantMsg("Sunny."),
+	})
+	actual := string(saneMarshal(items))
+	expected := `[{"type":"message","role":"system","content":[{"type":"input_text","text":"Be brief."}]},` +
+		`{"type":"message","role":"user","content":[{"type":"input_text","text":"Weather in Paris?"}]},` +
+		`{"type":"function_call","call_id":"call_2","name":"weather","arguments":"{\"city\":\"Paris\"}"},` +
+		`{"type":"function_call_output","call_id":"call_2","output":"sunny"},` +
+		`{"type":"function_call","call_id":"call_a","name":"weather","arguments":"{\"city\":\"Rome\"}"},` +
+		`{"type":"function_call_output","call_id":"call_a","output":"rainy"},` +
+		`{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Sunny."}]}]` + "\n"
+	if actual != expected {
+		t.Errorf("** ResponseInput:\n%s\nwanted:\n%s", actual, expected)
+	}
+}
+
+func TestCreateResponse(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/responses":
+			if string(body) != `{"input":[{"type":"function_call_output","call_id":"call_1","output":"sunny"}],"model":"gpt-4o","previous_response_id":"resp_1","tools":[{"type":"web_search_preview"}]}`+"\n" {
+				http.Error(w, "unexpected body "+string(body), http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"id": "resp_2", "status": "completed", "model": "gpt-4o-2024-08-06", "previous_response_id": "resp_1", "output": [
+				{"type": "web_search_call", "id": "ws_1", "status": "completed"},
+				{"type": "message", "id": "msg_1", "role": "assistant", "content": [
+					{"type": "output_text", "text": "It's sunny", "annotations": [{"type": "url_citation", "url": "https://example.com", "start_index": 0, "end_index": 4}]},
+					{"type": "output_text", "text": " in Paris."}
+				]},
+				{"type": "function_call", "id": "fc_1", "call_id": "call_2", "name": "book", "arguments": "{}"}
+			], "usage": {"input_tokens": 10, "input_tokens_details": {"cached_tokens": 4}, "output_tokens": 5, "output_tokens_details": {"reasoning_tokens": 2}, "total_tokens": 15}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/responses/resp_3":
+			w.Write([]byte(`{"id": "resp_3", "status": "failed", "error": {"code": "server_error", "message": "Boom"}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/responses/resp_2":
+			w.Write([]byte(`{"id": "resp_2", "object": "response", "deleted": true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	opt := ResponseOptions{
+		Model:              ModelChatGPT4o,
+		PreviousResponseID: "resp_1",
+		Tools:              []any{map[string]any{"type": "web_search_preview"}},
+	}
+	resp, err := CreateResponse(ctx, []ResponseItem{FunctionCallOutput("call_1", "sunny")}, opt, client, Credentials{})
+	if err != nil {
+		t.Fatalf("** CreateResponse: %v", err)
+	}
+	if a := resp.OutputText(); a != "It's sunny in Paris." {
+		t.Errorf("** OutputText = %q", a)
+	}
+	if calls := resp.FunctionCalls(); len(calls) != 1 || calls[0].CallID != "call_2" {
+		t.Errorf("** FunctionCalls = %+v", calls)
+	}
+	if a := resp.Output[1].Content[0].Annotations; len(a) != 1 || a[0].URL != "https://example.com" || a[0].EndIndex != 4 {
+		t.Errorf("** annotations = %+v", a)
+	}
+	expectedUsage := Usage{
+		PromptTokens:            10,
+		CompletionTokens:        5,
+		TotalTokens:             15,
+		PromptTokensDetails:     PromptTokensDetails{CachedTokens: 4},
+		CompletionTokensDetails: CompletionTokensDetails{ReasoningTokens: 2},
+	}
+	if a := resp.Usage.ChatUsage(); !reflect.DeepEqual(a, expectedUsage) {
+		t.Errorf("** ChatUsage = %+v, wanted %+v", a, expectedUsage)
+	}
+
+	resp, err = RetrieveResponse(ctx, "resp_3", client, Credentials{})
+	if err != nil || resp.Status != ResponseFailed || resp.Error.Message != "Boom" {
+		t.Errorf("** RetrieveResponse = %+v, %v", resp, err)
+	}
+
+	if err := DeleteResponse(ctx, "resp_2", client, Credentials{}); err != nil {
+		t.Errorf("** DeleteResponse: %v", err)
+	}
+}
+
+func TestStreamResponse(t *testing.T) {
+	var stream string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"stream":true`) {
+			http.Error(w, "not streaming", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(stream))
+	})
+	ctx := context.Background()
+
+	// event names come from the SSE event field, which the data doesn't have to repeat
+	stream = "event: response.created\ndata: {\"type\":\"response.created\",\"sequence_number\":0,\"response\":{\"id\":\"resp_1\",\"status\":\"in_progress\"}}\n\n" +
+		"event: response.output_text.delta\nid: 7\ndata: {\"sequence_number\":1,\"item_id\":\"msg_1\",\"delta\":\"Hel\"}\n\n" +
+		"event: response.output_text.delta\ndata: {\"sequence_number\":2,\"item_id\":\"msg_1\",\"delta\":\"lo\"}\n\n" +
+		"event: response.completed\ndata: {\"type\":\"response.completed\",\"sequence_number\":3,\"response\":{\"id\":\"resp_1\",\"status\":\"completed\",\"output\":[{\"type\":\"message\",\"role\":\"assistant\",\"content\":[{\"type\":\"output_text\",\"text\":\"Hello\"}]}]}}\n\n"
+
+	var events []string
+	var text string
+	resp, err := StreamResponse(ctx, []ResponseItem{InputText(User, "Hi")}, ResponseOptions{Model: ModelChatGPT4o}, client, Credentials{}, func(ev *ResponseEvent) error {
+		events = append(events, ev.Type+"#"+ev.EventID)
+		if ev.Type == ResponseEventOutputTextDelta {
+			text += ev.Delta
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("** StreamResponse: %v", err)
+	}
+	if expected := []string{"response.created#", "response.output_text.delta#7", "response.output_text.delta#", "response.completed#"}; !reflect.DeepEqual(events, expected) {
+		t.Errorf("** events = %v, wanted %v", events, expected)
+	}
+	if text != "Hello" || resp.ID != "resp_1" || resp.OutputText() != "Hello" {
+		t.Errorf("** StreamResponse = %+v, text %q", resp, text)
+	}
+
+	stream = "event: error\ndata: {\"type\":\"error\",\"code\":\"rate_limit_exceeded\",\"message\":\"Slow down\"}\n\n"
+	_, err = StreamResponse(ctx, nil, ResponseOptions{Model: ModelChatGPT4o}, client, Credentials{}, func(ev *ResponseEvent) error {
+		return nil
+	})
+	var e *Error
+	if !errors.As(err, &e) || e.Type != "rate_limit_exceeded" || e.Message != "Slow down" {
+		t.Errorf("** StreamResponse error event: %v", err)
+	}
+
+	stream = "event: response.failed\ndata: {\"response\":{\"id\":\"resp_2\",\"status\":\"failed\",\"error\":{\"code\":\"server_error\",\"message\":\"Boom\"}}}\n\n"
+	_, err = StreamResponse(ctx, nil, ResponseOptions{Model: ModelChatGPT4o}, client, Credentials{}, func(ev *ResponseEvent) error {
+		return nil
+	})
+	if !errors.As(err, &e) || e.Type != "server_error" {
+		t.Errorf("** StreamResponse failed response: %v", err)
+	}
+}
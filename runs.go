@@ -0,0 +1,319 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type RunStatus string
+
+const (
+	RunQueued         RunStatus = "queued"
+	RunInProgress     RunStatus = "in_progress"
+	RunRequiresAction RunStatus = "requires_action"
+	RunCancelling     RunStatus = "cancelling"
+	RunCancelled      RunStatus = "cancelled"
+	RunFailed         RunStatus = "failed"
+	RunCompleted      RunStatus = "completed"
+	RunIncomplete     RunStatus = "incomplete"
+	RunExpired        RunStatus = "expired"
+)
+
+// IsFinal returns whether the run has stopped and its status won't change any more.
+func (s RunStatus) IsFinal() bool {
+	return s == RunCancelled || s == RunFailed || s == RunCompleted || s == RunIncomplete || s == RunExpired
+}
+
+// RunParams describe a run to create; only AssistantID is required.
+// The other fields override the settings of the assistant for this run.
+type RunParams struct {
+	AssistantID            string                `json:"assistant_id"`
+	Model                  string                `json:"model,omitempty"`
+	Instructions           string                `json:"instructions,omitempty"`
+	AdditionalInstructions string                `json:"additional_instructions,omitempty"`
+	AdditionalMessages     []ThreadMessageParams `json:"additional_messages,omitempty"`
+	Tools                  []any                 `json:"tools,omitempty"`
+	ToolChoice             any                   `json:"tool_choice,omitempty"`
+	ParallelToolCalls      *bool                 `json:"parallel_tool_calls,omitempty"`
+	MaxPromptTokens        int                   `json:"max_prompt_tokens,omitempty"`
+	MaxCompletionTokens    int                   `json:"max_completion_tokens,omitempty"`
+	Metadata               map[string]string     `json:"metadata,omitempty"`
+}
+
+// Run is an execution of an assistant on a thread.
+type Run struct {
+	ID          string    `json:"id"`
+	CreatedAt   int64     `json:"created_at"`
+	ThreadID    string    `json:"thread_id"`
+	AssistantID string    `json:"assistant_id"`
+	Status      RunStatus `json:"status"`
+	Model       string    `json:"model"`
+
+	// RequiredAction lists the tool calls to answer via SubmitToolOutputs
+	// when Status is RunRequiresAction.
+	RequiredAction *struct {
+		Type              string `json:"type"` // "submit_tool_outputs"
+		SubmitToolOutputs struct {
+			ToolCalls []RunToolCall `json:"tool_calls"`
+		} `json:"submit_tool_outputs"`
+	} `json:"required_action"`
+
+	LastError *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"last_error"`
+
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+
+	// Usage is only set once the run is final.
+	Usage *Usage `json:"usage"`
+
+	Metadata map[string]string `json:"metadata"`
+}
+
+// ToolCalls returns the tool calls the run is waiting for, if any.
+func (r *Run) ToolCalls() []RunToolCall {
+	if r.RequiredAction == nil {
+		return nil
+	}
+	return r.RequiredAction.SubmitToolOutputs.ToolCalls
+}
+
+// RunToolCall is a function call requested by a run.
+type RunToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"` // "function"
+	Function FunctionCall `json:"function"`
+}
+
+// ToolOutput answers a RunToolCall.
+type ToolOutput struct {
+	ToolCallID string `json:"tool_call_id"`
+	Output     string `json:"output"`
+}
+
+// CreateRun starts running an assistant on a thread. Use WaitForRun to wait for it,
+// or StreamRun to create and stream it in one go.
+func CreateRun(ctx context.Context, threadID string, params RunParams, client *http.Client, creds Credentials) (*Run, error) {
+	const callID = "CreateRun"
+	var resp Run
+	err := post(ctx, callID, runsEndpoint(threadID), client, creds, &params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RetrieveRun returns the current state of a run.
+func RetrieveRun(ctx context.Context, threadID, runID string, client *http.Client, creds Credentials) (*Run, error) {
+	const callID = "RetrieveRun"
+	var resp Run
+	err := get(ctx, callID, runsEndpoint(threadID)+"/"+url.PathEscape(runID), client, creds, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CancelRun cancels an in-progress run.
+func CancelRun(ctx context.Context, threadID, runID string, client *http.Client, creds Credentials) (*Run, error) {
+	const callID = "CancelRun"
+	var resp Run
+	err := post(ctx, callID, runsEndpoint(threadID)+"/"+url.PathEscape(runID)+"/cancel", client, creds, struct{}{}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitToolOutputs answers all tool calls of a run in RunRequiresAction status, letting it continue.
+func SubmitToolOutputs(ctx context.Context, threadID, runID string, outputs []ToolOutput, client *http.Client, creds Credentials) (*Run, error) {
+	const callID = "SubmitToolOutputs"
+	req := &submitToolOutputsRequest{ToolOutputs: outputs}
+	var resp Run
+	err := post(ctx, callID, runsEndpoint(threadID)+"/"+url.PathEscape(runID)+"/submit_tool_outputs", client, creds, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// WaitForRun polls the run every interval until it's final or requires action, and returns its state.
+func WaitForRun(ctx context.Context, threadID, runID string, interval time.Duration, client *http.Client, creds Credentials) (*Run, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		run, err := RetrieveRun(ctx, threadID, runID, client, creds)
+		if err != nil {
+			return nil, err
+		}
+		if run.Status.IsFinal() || run.Status == RunRequiresAction {
+			return run, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Names of RunEvent. Other events (e.g. thread.run.step.*) are passed through as well.
+const (
+	RunEventThreadCreated     = "thread.created"
+	RunEventCreated           = "thread.run.created"
+	RunEventQueued            = "thread.run.queued"
+	RunEventInProgress        = "thread.run.in_progress"
+	RunEventRequiresAction    = "thread.run.requires_action"
+	RunEventCompleted         = "thread.run.completed"
+	RunEventIncomplete        = "thread.run.incomplete"
+	RunEventFailed            = "thread.run.failed"
+	RunEventCancelling        = "thread.run.cancelling"
+	RunEventCancelled         = "thread.run.cancelled"
+	RunEventExpired           = "thread.run.expired"
+	RunEventMessageCreated    = "thread.message.created"
+	RunEventMessageInProgress = "thread.message.in_progress"
+	RunEventMessageDelta      = "thread.message.delta"
+	RunEventMessageCompleted  = "thread.message.completed"
+	RunEventMessageIncomplete = "thread.message.incomplete"
+	RunEventError             = "error"
+)
+
+// RunEvent is a streaming event of StreamRun and StreamSubmitToolOutputs.
+// Depending on the event name, one of Run, Message and Delta is set.
+type RunEvent struct {
+	Event string
+
+	Run     *Run           // thread.run.* events, except thread.run.step.*
+	Message *ThreadMessage // thread.message.* events, except thread.message.delta
+	Delta   *MessageDelta  // thread.message.delta
+
+	// Data is the raw JSON of the event, e.g. to handle thread.run.step.* events.
+	Data json.RawMessage
+}
+
+// MessageDelta is an incremental update of a message being generated.
+type MessageDelta struct {
+	ID    string `json:"id"` // of the message
+	Delta struct {
+		Content []struct {
+			Index int    `json:"index"`
+			Type  string `json:"type"`
+			Text  *struct {
+				Value string `json:"value"`
+			} `json:"text,omitempty"`
+		} `json:"content"`
+	} `json:"delta"`
+}
+
+// Text returns the new text of the delta.
+func (d *MessageDelta) Text() string {
+	var buf strings.Builder
+	for _, c := range d.Delta.Content {
+		if c.Text != nil {
+			buf.WriteString(c.Text.Value)
+		}
+	}
+	return buf.String()
+}
+
+// StreamRun creates a run and streams its events into f. Returns the state of the run
+// from the last run event, which is either final or RunRequiresAction; in the latter
+// case, continue via StreamSubmitToolOutputs.
+func StreamRun(ctx context.Context, threadID string, params RunParams, client *http.Client, creds Credentials, f func(ev *RunEvent) error) (*Run, error) {
+	const callID = "StreamRun"
+	req := &streamRunRequest{params, true}
+	return streamRun(ctx, callID, runsEndpoint(threadID), req, client, creds, f)
+}
+
+// StreamSubmitToolOutputs is like SubmitToolOutputs, but streams the events of the continued run like StreamRun.
+func StreamSubmitToolOutputs(ctx context.Context, threadID, runID string, outputs []ToolOutput, client *http.Client, creds Credentials, f func(ev *RunEvent) error) (*Run, error) {
+	const callID = "StreamSubmitToolOutputs"
+	req := &submitToolOutputsRequest{ToolOutputs: outputs, Stream: true}
+	return streamRun(ctx, callID, runsEndpoint(threadID)+"/"+url.PathEscape(runID)+"/submit_tool_outputs", req, client, creds, f)
+}
+
+func streamRun(ctx context.Context, callID, endpoint string, req any, client *http.Client, creds Credentials, f func(ev *RunEvent) error) (*Run, error) {
+	var run *Run
+	var streamErr error
+	err := post(ctx, callID, endpoint, client, creds, req, func(id, event string, data []byte) error {
+		ev := &RunEvent{Event: event, Data: data}
+		switch {
+		case event == RunEventError:
+			var e struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			}
+			var errResp struct {
+				Error *struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if json.Unmarshal(data, &errResp) == nil && errResp.Error != nil {
+				e = *errResp.Error
+			} else {
+				_ = json.Unmarshal(data, &e)
+			}
+			streamErr = &Error{
+				CallID:            callID,
+				Type:              e.Code,
+				Message:           e.Message,
+				RawResponseBody:   data,
+				PrintResponseBody: e.Message == "",
+			}
+			return streamErr
+		case event == RunEventMessageDelta:
+			ev.Delta = new(MessageDelta)
+			if err := json.Unmarshal(data, ev.Delta); err != nil {
+				return err
+			}
+		case strings.HasPrefix(event, "thread.run.step."):
+			break
+		case strings.HasPrefix(event, "thread.run."):
+			ev.Run = new(Run)
+			if err := json.Unmarshal(data, ev.Run); err != nil {
+				return err
+			}
+			run = ev.Run
+		case strings.HasPrefix(event, "thread.message."):
+			ev.Message = new(ThreadMessage)
+			if err := json.Unmarshal(data, ev.Message); err != nil {
+				return err
+			}
+		}
+		return f(ev)
+	})
+	if streamErr != nil {
+		return nil, streamErr
+	} else if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, &Error{
+			CallID:  callID,
+			Message: "stream ended without run events",
+		}
+	}
+	return run, nil
+}
+
+func runsEndpoint(threadID string) string {
+	return "https://api.openai.com/v1/threads/" + url.PathEscape(threadID) + "/runs"
+}
+
+type streamRunRequest struct {
+	RunParams
+	Stream bool `json:"stream"`
+}
+
+type submitToolOutputsRequest struct {
+	ToolOutputs []ToolOutput `json:"tool_outputs"`
+	Stream      bool         `json:"stream,omitempty"`
+}
@@ -0,0 +1,110 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamRun(t *testing.T) {
+	var stream string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/v1/threads/thread_1/runs":
+			if string(body) != `{"assistant_id":"asst_1","stream":true}`+"\n" {
+				http.Error(w, "unexpected body "+string(body), http.StatusBadRequest)
+				return
+			}
+			stream = "event: thread.run.created\ndata: {\"id\":\"run_1\",\"thread_id\":\"thread_1\",\"status\":\"queued\"}\n\n" +
+				"event: thread.run.step.created\ndata: {\"id\":\"step_1\",\"type\":\"tool_calls\"}\n\n" +
+				"event: thread.run.requires_action\ndata: {\"id\":\"run_1\",\"thread_id\":\"thread_1\",\"status\":\"requires_action\",\"required_action\":{\"type\":\"submit_tool_outputs\",\"submit_tool_outputs\":{\"tool_calls\":[{\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"weather\",\"arguments\":\"{\\\"city\\\":\\\"Paris\\\"}\"}}]}}}\n\n" +
+				"event: done\ndata: [DONE]\n\n"
+		case r.URL.Path == "/v1/threads/thread_1/runs/run_1/submit_tool_outputs":
+			if string(body) != `{"tool_outputs":[{"tool_call_id":"call_1","output":"sunny"}],"stream":true}`+"\n" {
+				http.Error(w, "unexpected body "+string(body), http.StatusBadRequest)
+				return
+			}
+			stream = "event: thread.message.created\ndata: {\"id\":\"msg_1\",\"role\":\"assistant\",\"content\":[]}\n\n" +
+				"event: thread.message.delta\ndata: {\"id\":\"msg_1\",\"delta\":{\"content\":[{\"index\":0,\"type\":\"text\",\"text\":{\"value\":\"Sun\"}}]}}\n\n" +
+				"event: thread.message.delta\ndata: {\"id\":\"msg_1\",\"delta\":{\"content\":[{\"index\":0,\"type\":\"text\",\"text\":{\"value\":\"ny.\"}}]}}\n\n" +
+				"event: thread.message.completed\ndata: {\"id\":\"msg_1\",\"role\":\"assistant\",\"content\":[{\"type\":\"text\",\"text\":{\"value\":\"Sunny.\",\"annotations\":[]}}]}\n\n" +
+				"event: thread.run.completed\ndata: {\"id\":\"run_1\",\"status\":\"completed\",\"usage\":{\"prompt_tokens\":20,\"completion_tokens\":3,\"total_tokens\":23}}\n\n" +
+				"event: done\ndata: [DONE]\n\n"
+		case r.URL.Path == "/v1/threads/thread_2/runs":
+			stream = "event: error\ndata: {\"code\":\"server_error\",\"message\":\"Boom\"}\n\n"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(stream))
+	})
+	ctx := context.Background()
+
+	var events []string
+	record := func(ev *RunEvent) error {
+		events = append(events, ev.Event)
+		if ev.Delta != nil {
+			events = append(events, ev.Delta.Text())
+		}
+		return nil
+	}
+
+	run, err := StreamRun(ctx, "thread_1", RunParams{AssistantID: "asst_1"}, client, Credentials{}, record)
+	if err != nil {
+		t.Fatalf("** StreamRun: %v", err)
+	}
+	calls := run.ToolCalls()
+	if run.Status != RunRequiresAction || len(calls) != 1 || calls[0].Function.Name != "weather" {
+		t.Fatalf("** StreamRun = %+v", run)
+	}
+
+	run, err = StreamSubmitToolOutputs(ctx, "thread_1", run.ID, []ToolOutput{{calls[0].ID, "sunny"}}, client, Credentials{}, record)
+	if err != nil {
+		t.Fatalf("** StreamSubmitToolOutputs: %v", err)
+	}
+	if run.Status != RunCompleted || run.Usage == nil || run.Usage.TotalTokens != 23 {
+		t.Errorf("** StreamSubmitToolOutputs = %+v", run)
+	}
+
+	expected := []string{
+		RunEventCreated, "thread.run.step.created", RunEventRequiresAction,
+		RunEventMessageCreated, RunEventMessageDelta, "Sun", RunEventMessageDelta, "ny.", RunEventMessageCompleted, RunEventCompleted,
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("** events:\n%q\nwanted:\n%q", events, expected)
+	}
+
+	_, err = StreamRun(ctx, "thread_2", RunParams{AssistantID: "asst_1"}, client, Credentials{}, record)
+	var e *Error
+	if !errors.As(err, &e) || e.Type != "server_error" || e.Message != "Boom" {
+		t.Errorf("** StreamRun error event: %v", err)
+	}
+}
+
+func TestWaitForRun(t *testing.T) {
+	var polls int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/threads/thread_1/runs/run_1" {
+			http.NotFound(w, r)
+			return
+		}
+		polls++
+		if polls < 3 {
+			w.Write([]byte(`{"id": "run_1", "status": "in_progress"}`))
+		} else {
+			w.Write([]byte(`{"id": "run_1", "status": "failed", "last_error": {"code": "rate_limit_exceeded", "message": "Slow down"}}`))
+		}
+	})
+
+	run, err := WaitForRun(context.Background(), "thread_1", "run_1", time.Millisecond, client, Credentials{})
+	if err != nil || run.Status != RunFailed || polls != 3 || !strings.Contains(run.LastError.Message, "Slow") {
+		t.Errorf("** WaitForRun = %+v, %v after %d polls", run, err, polls)
+	}
+}
@@ -5,18 +5,20 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"strconv"
 )
 
 var (
 	dataPrefixBytes     = []byte("data:")
 	eventPrefixBytes    = []byte("event:")
 	idPrefixBytes       = []byte("id:")
+	retryPrefixBytes    = []byte("retry:")
 	bomBytes            = []byte{0xEF, 0xBB, 0xBF}
 	errCloseEventStream = errors.New("close event stream")
 )
 
 // Note: this version doesn't handle LF line terminators because the chance
-// of OpenAI using those is nil. Retry fields have also been removed.
+// of OpenAI using those is nil. Retry fields are passed to retryf, which can be nil.
 func parseEventStream(r io.Reader, maxSize int, f func(id, event string, data []byte) error, retryf func(ms uint64)) error {
 	scanner := bufio.NewScanner(r)
 
@@ -53,6 +55,13 @@ func parseEventStream(r io.Reader, maxSize int, f func(id, event string, data []
 
 		} else if data, ok := bytes.CutPrefix(line, idPrefixBytes); ok {
 			id = string(stripLeadingSpace(data))
+
+		} else if data, ok := bytes.CutPrefix(line, retryPrefixBytes); ok {
+			// per spec, retry fields that aren't all ASCII digits are ignored
+			ms, err := strconv.ParseUint(string(stripLeadingSpace(data)), 10, 64)
+			if err == nil && retryf != nil {
+				retryf(ms)
+			}
 		} // ignore unknown lines
 	}
 	// incomplete events are discarded
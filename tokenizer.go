@@ -3,19 +3,32 @@ package openai
 import (
 	"bytes"
 	_ "embed"
-	"log"
-	"math"
+	"errors"
+	"fmt"
+	"log/slog"
 	"strings"
 	"sync"
 	"unicode"
+	"unicode/utf8"
 )
 
 const (
-	chatTokenOverhead       = 2
-	chatTokenOverheadPerMsg = 5
+	// chatReplyPrimingTokens is the overhead of <|start|>assistant<|message|>
+	// that every reply is primed with.
+	chatReplyPrimingTokens = 3
+
+	// functionsTokenOverhead is the overhead of including function definitions
+	// into the prompt, and functionsSystemMsgDiscount is subtracted from it
+	// when the prompt already has a system message to merge the definitions into.
+	functionsTokenOverhead     = 9
+	functionsSystemMsgDiscount = 4
+
+	// functionCallTokenOverhead is the framing of a function call within an assistant message.
+	functionCallTokenOverhead = 3
 )
 
 // TokenCount counts GPT-3 tokens in the given text for the given model.
+// See TokenizerEncoding for how exact the count is for newer models.
 func TokenCount(text, model string) int {
 	var result int
 	EncodeEnum(text, model, func(token int) {
@@ -24,20 +37,60 @@ func TokenCount(text, model string) int {
 	return result
 }
 
+// MsgTokenCount counts the tokens the given message occupies within a chat prompt,
+// including its role, name, function call and the per-message framing of the model.
 func MsgTokenCount(msg Msg, model string) int {
-	// We don't know the actual metaencoding, but it must be something similar.
-	// Add a bit just to be sure.
-	return TokenCount(msg.Content, model) + chatTokenOverheadPerMsg
+	perMsg, perName := chatMsgFraming(model)
+	result := perMsg + TokenCount(string(msg.Role), model) + TokenCount(msg.Content, model)
+	if msg.Name != "" {
+		result += perName + TokenCount(msg.Name, model)
+	}
+	if call := msg.FunctionCall; call != nil {
+		result += functionCallTokenOverhead + TokenCount(call.Name, model) + TokenCount(call.Arguments, model)
+	}
+	for _, call := range msg.ToolCalls {
+		result += functionCallTokenOverhead + TokenCount(call.Function.Name, model) + TokenCount(call.Function.Arguments, model)
+	}
+	return result
 }
 
+// ChatTokenCount counts the prompt tokens of the given chat, including the priming
+// of the reply. Use ChatPromptTokenCount to also account for function and tool definitions.
 func ChatTokenCount(msgs []Msg, model string) int {
-	result := chatTokenOverhead
+	result := chatReplyPrimingTokens
 	for _, msg := range msgs {
 		result += MsgTokenCount(msg, model)
 	}
 	return result
 }
 
+// ChatPromptTokenCount estimates the number of prompt tokens Chat will be billed for
+// when called with the given messages and options, including opt.Functions and opt.Tools.
+func ChatPromptTokenCount(msgs []Msg, opt Options) int {
+	result := ChatTokenCount(msgs, opt.Model)
+	if defs := functionDefinitions(opt); len(defs) > 0 {
+		result += TokenCount(formatFunctionDefinitions(defs), opt.Model) + functionsTokenOverhead
+		for _, msg := range msgs {
+			if msg.Role == System {
+				result -= functionsSystemMsgDiscount
+				break
+			}
+		}
+	}
+	return result
+}
+
+// chatMsgFraming returns the number of tokens added to every message and to every name
+// by the chat markup of the given model.
+func chatMsgFraming(model string) (perMsg, perName int) {
+	if BaseModel(model) == "gpt-3.5-turbo-0301" {
+		// every message follows <|start|>{role/name}\n{content}<|end|>\n,
+		// and if there's a name, the role is omitted
+		return 4, -1
+	}
+	return 3, 1
+}
+
 func Encode(text, model string) []int {
 	var result []int
 	EncodeEnum(text, model, func(token int) {
@@ -46,48 +99,165 @@ func Encode(text, model string) []int {
 	return result
 }
 
+// EncodeEnum calls f for every token of the given text, without allocating a slice.
 func EncodeEnum(text, model string, f func(int)) {
-	initEncoder()
+	initModelEncoder(model)
+	var enc bpeEncoder
 	split(text, func(chunk string) {
-		var tokens []string
-		for _, b := range []byte(chunk) {
-			tokens = append(tokens, string(byteEncoding[b]))
-		}
-		tokens = bpe(tokens)
-		for _, token := range tokens {
-			v, ok := tokenEncodings[token]
-			if ok {
-				f(v)
-			} else {
-				log.Printf("no encoding found for token %q", token)
-			}
+		for _, token := range enc.encodeChunk(chunk) {
+			f(token)
 		}
 	})
 }
 
-func Decode(tokens []int, model string) string {
-	decoderOnce.Do(func() {
-		initEncoder()
-		tokenDecodings = make(map[int][]rune)
-		for k, v := range tokenEncodings {
-			tokenDecodings[v] = []rune(k)
-		}
-		byteDecoding = make(map[rune]byte)
-		for b, s := range byteEncoding {
-			byteDecoding[s] = byte(b)
+// TokenSpan is a token along with the range of bytes it covers in the encoded text.
+// Note that a token can start or end in the middle of a multi-byte UTF-8 character.
+type TokenSpan struct {
+	Token int
+	Start int
+	End   int
+}
+
+// Text returns the part of text covered by the span.
+func (span TokenSpan) Text(text string) string {
+	return text[span.Start:span.End]
+}
+
+// EncodeWithOffsets is like Encode, but also returns the byte range of every token
+// in the original text, e.g. for highlighting tokens or aligning logprobs with the text.
+// The spans are contiguous and cover the entire text.
+func EncodeWithOffsets(text, model string) []TokenSpan {
+	initModelEncoder(model)
+	var enc bpeEncoder
+	var result []TokenSpan
+	offset := 0
+	split(text, func(chunk string) {
+		// BPE results always add up to the chunk, so offsets follow from token lengths
+		for _, token := range enc.encodeChunk(chunk) {
+			end := offset + len(tokenBytes[token])
+			result = append(result, TokenSpan{token, offset, end})
+			offset = end
 		}
 	})
+	return result
+}
+
+// Decode converts tokens back into text. Panics on unknown tokens; use DecodeE
+// when decoding tokens that did not come from Encode.
+func Decode(tokens []int, model string) string {
+	return must(DecodeE(tokens, model))
+}
+
+// DecodeE converts tokens back into text, returning an error on unknown tokens.
+func DecodeE(tokens []int, model string) (string, error) {
+	initModelEncoder(model)
 	var buf strings.Builder
 	for _, token := range tokens {
-		runes, ok := tokenDecodings[token]
-		if !ok {
-			panic("no decoding found for token")
+		b, err := decodeToken(token)
+		if err != nil {
+			return "", err
 		}
-		for _, r := range runes {
-			buf.WriteByte(byteDecoding[r])
+		buf.WriteString(b)
+	}
+	return buf.String(), nil
+}
+
+func decodeToken(token int) (string, error) {
+	if token < 0 || token >= len(tokenBytes) || tokenBytes[token] == "" {
+		return "", fmt.Errorf("no decoding found for token %d", token)
+	}
+	return tokenBytes[token], nil
+}
+
+// TokenDecoder decodes a stream of tokens incrementally. A single UTF-8 character
+// can be split across several tokens, so TokenDecoder holds back incomplete
+// characters until the tokens completing them arrive.
+type TokenDecoder struct {
+	pending []byte
+}
+
+// NewTokenDecoder returns a TokenDecoder for the tokens Encode produces for the
+// given model, i.e. ones of the encoding returned by TokenizerEncoding.
+func NewTokenDecoder(model string) *TokenDecoder {
+	initModelEncoder(model)
+	return &TokenDecoder{}
+}
+
+// Add decodes the next token, returning the text that is complete so far.
+// The result can be empty if the token only contains a part of a character.
+func (d *TokenDecoder) Add(token int) (string, error) {
+	b, err := decodeToken(token)
+	if err != nil {
+		return "", err
+	}
+	d.pending = append(d.pending, b...)
+
+	n := len(d.pending) - incompleteUTF8Suffix(d.pending)
+	result := string(d.pending[:n])
+	d.pending = append(d.pending[:0], d.pending[n:]...)
+	return result, nil
+}
+
+// Flush returns any bytes still held back, which happens when the stream ends
+// in the middle of a character. The result is not valid UTF-8 in that case.
+func (d *TokenDecoder) Flush() string {
+	result := string(d.pending)
+	d.pending = d.pending[:0]
+	return result
+}
+
+// incompleteUTF8Suffix returns the length of an incomplete UTF-8 sequence at the end of b.
+func incompleteUTF8Suffix(b []byte) int {
+	n := len(b)
+	for i := n - 1; i >= 0 && i >= n-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return 0
+			}
+			return n - i
+		}
+	}
+	return 0
+}
+
+// ErrUnsupportedEncoding is returned (wrapped) by TokenizerEncoding for models
+// whose encoding the built-in tokenizer does not implement.
+var ErrUnsupportedEncoding = errors.New("unsupported tokenizer encoding")
+
+// builtinEncoding is the encoding of the embedded data files, the only one implemented.
+const builtinEncoding = EncodingR50k
+
+// TokenizerEncoding returns the encoding the built-in tokenizer uses for the given model.
+// That's the model's own encoding (see ModelEncoding) if the tokenizer implements it.
+// Otherwise it's EncodingR50k, token counts are only approximate, and the error wraps
+// ErrUnsupportedEncoding (or ErrUnknownModel for models missing from the registry).
+func TokenizerEncoding(model string) (string, error) {
+	enc, err := ModelEncoding(model)
+	if err != nil {
+		return builtinEncoding, err
+	}
+	if !isBuiltinEncoding(enc) {
+		return builtinEncoding, fmt.Errorf("%w %s of %q, approximated with %s", ErrUnsupportedEncoding, enc, model, builtinEncoding)
+	}
+	return builtinEncoding, nil
+}
+
+func isBuiltinEncoding(enc string) bool {
+	return enc == "" || enc == builtinEncoding
+}
+
+// approximatedEncodings holds the unsupported encodings already warned about.
+var approximatedEncodings sync.Map
+
+// initModelEncoder prepares the tokenizer for the given model, warning (once per
+// encoding) if the model's encoding is approximated, see TokenizerEncoding.
+func initModelEncoder(model string) {
+	initEncoder()
+	if enc, err := ModelEncoding(model); err == nil && !isBuiltinEncoding(enc) {
+		if _, warned := approximatedEncodings.LoadOrStore(enc, true); !warned {
+			slog.Warn("openai: tokenizer encoding not supported, token counts are approximate", "encoding", enc, "model", model, "approximation", builtinEncoding)
 		}
 	}
-	return buf.String()
 }
 
 //go:embed tokenizer-tokens.bin
@@ -96,123 +266,282 @@ var rawTokens []byte
 //go:embed tokenizer-bpe.bin
 var rawMerges string
 
+const (
+	// chunkCacheMaxSize bounds the number of chunks remembered by the encoder.
+	// The cache is simply reset when it fills up; common words quickly make it back.
+	chunkCacheMaxSize = 16384
+
+	// chunkCacheMaxLen is the longest chunk worth caching. Longer ones rarely repeat.
+	chunkCacheMaxLen = 32
+)
+
 var (
-	encoderOnce    sync.Once
-	bpeMerges      []*bpeMerge
-	bpeMergeIndex  map[bpePair]int
-	tokenEncodings map[string]int
-	byteEncoding   [256]rune
+	encoderOnce sync.Once
+
+	// tokenIDs maps raw token bytes to token IDs.
+	tokenIDs map[string]int
+
+	// tokenBytes maps token IDs to raw token bytes; unused IDs map to "".
+	tokenBytes []string
+
+	// byteTokens maps every single byte to its token ID.
+	byteTokens [256]int
+
+	// bpeRanks maps a pair of adjacent token IDs (see bpePairKey) to its merge.
+	bpeRanks map[uint64]bpeMerge
 )
 
 var (
-	decoderOnce    sync.Once
-	tokenDecodings map[int][]rune
-	byteDecoding   map[rune]byte
+	chunkCacheMut sync.Mutex
+	chunkCache    = make(map[string][]int)
 )
 
+type bpeMerge struct {
+	Rank   int32
+	Result int32
+}
+
+func bpePairKey(first, second int32) uint64 {
+	return uint64(uint32(first))<<32 | uint64(uint32(second))
+}
+
 func initEncoder() {
 	encoderOnce.Do(func() {
-		tokenEncodings = make(map[string]int)
-		for i, token := range bytes.Split(rawTokens, []byte{0}) {
+		// Data files encode bytes as code points, see byteEncoding.
+		var byteDecoding = make(map[rune]byte)
+		for b, r := range byteEncoding() {
+			byteDecoding[r] = byte(b)
+		}
+		decodeBytes := func(s string) string {
+			var buf strings.Builder
+			for _, r := range s {
+				buf.WriteByte(byteDecoding[r])
+			}
+			return buf.String()
+		}
+
+		tokens := bytes.Split(rawTokens, []byte{0})
+		tokenIDs = make(map[string]int, len(tokens))
+		tokenBytes = make([]string, len(tokens))
+		for i, token := range tokens {
 			if len(token) == 0 {
 				continue
 			}
-			tokenEncodings[string(token)] = i
+			raw := decodeBytes(string(token))
+			tokenIDs[raw] = i
+			tokenBytes[i] = raw
+		}
+		for b := 0; b <= 255; b++ {
+			byteTokens[b] = tokenIDs[string([]byte{byte(b)})]
 		}
 
-		for _, line := range strings.FieldsFunc(rawMerges, isNewLine)[1:] {
+		lines := strings.FieldsFunc(rawMerges, isNewLine)[1:]
+		bpeRanks = make(map[uint64]bpeMerge, len(lines))
+		for i, line := range lines {
 			first, second, ok := strings.Cut(line, " ")
 			if !ok {
 				panic("invalid bpe line")
 			}
-			bpeMerges = append(bpeMerges, &bpeMerge{first, second, first + second})
+			first, second = decodeBytes(first), decodeBytes(second)
+			firstID, ok1 := tokenIDs[first]
+			secondID, ok2 := tokenIDs[second]
+			resultID, ok3 := tokenIDs[first+second]
+			if !ok1 || !ok2 || !ok3 {
+				slog.Warn("openai: no encoding found for bpe merge", "first", first, "second", second)
+				continue
+			}
+			key := bpePairKey(int32(firstID), int32(secondID))
+			if _, found := bpeRanks[key]; !found {
+				bpeRanks[key] = bpeMerge{int32(i), int32(resultID)}
+			}
+		}
+	})
+}
+
+// byteEncoding returns the mapping of bytes to code points used by the data files.
+//
+// TODO: this “UTF8 bytes to code points” encoding seems entirely pointless.
+// If that's correct, preprocess data files to undo this encoding, and then get rid of the code.
+func byteEncoding() [256]rune {
+	var result [256]rune
+	for b := '!'; b <= '~'; b++ {
+		result[b] = rune(b)
+	}
+	for b := '¡'; b <= '¬'; b++ {
+		result[b] = rune(b)
+	}
+	for b := '®'; b <= 'ÿ'; b++ {
+		result[b] = rune(b)
+	}
+	var next rune = 256
+	for b := 0; b <= 255; b++ {
+		if result[b] == 0 {
+			result[b] = next
+			next++
+		}
+	}
+	return result
+}
+
+// bpeEncoder holds reusable buffers for encoding chunks. The zero value is ready to use.
+type bpeEncoder struct {
+	nodes  []bpeNode
+	queue  bpeQueue
+	result []int
+}
+
+// bpeNode is an element of a doubly-linked list of parts being merged.
+type bpeNode struct {
+	token      int32
+	prev, next int32 // -1 if none
+}
+
+// bpeCandidate is a possible merge of nodes[left] and its next node.
+type bpeCandidate struct {
+	rank  int32
+	left  int32
+	first int32 // token of the left node at the time the candidate was queued
+	next  int32 // token of the right node at the time the candidate was queued
+}
+
+// encodeChunk returns the tokens of a single chunk produced by split.
+// The result is only valid until the next call.
+func (enc *bpeEncoder) encodeChunk(chunk string) []int {
+	if id, ok := tokenIDs[chunk]; ok {
+		enc.result = append(enc.result[:0], id)
+		return enc.result
+	}
+
+	cacheable := len(chunk) <= chunkCacheMaxLen
+	if cacheable {
+		chunkCacheMut.Lock()
+		cached, ok := chunkCache[chunk]
+		chunkCacheMut.Unlock()
+		if ok {
+			return cached
 		}
-		bpeMergeIndex = make(map[bpePair]int)
-		for i, merge := range bpeMerges {
-			bpeMergeIndex[bpePair{merge.First, merge.Second}] = i
+	}
+
+	enc.bpe(chunk)
+
+	if cacheable {
+		cached := make([]int, len(enc.result))
+		copy(cached, enc.result)
+		chunkCacheMut.Lock()
+		if len(chunkCache) >= chunkCacheMaxSize {
+			chunkCache = make(map[string][]int)
 		}
+		chunkCache[chunk] = cached
+		chunkCacheMut.Unlock()
+	}
+	return enc.result
+}
 
-		// TODO: this “UTF8 bytes to code points” encoding seems entirely pointless.
-		// If that's correct, preprocess data files to undo this encoding, and then get rid of the code.
-		for b := '!'; b <= '~'; b++ {
-			byteEncoding[b] = rune(b)
+// bpe splits chunk into bytes and merges consecutive pairs of tokens according to bpeRanks,
+// lowest rank first, until no further merging is possible. Stores the result in enc.result.
+//
+// Merges are kept in a priority queue, and parts in a linked list, so that every merge
+// only needs to look at its immediate neighbors instead of rescanning the entire chunk.
+func (enc *bpeEncoder) bpe(chunk string) {
+	n := len(chunk)
+	nodes := enc.nodes[:0]
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, bpeNode{
+			token: int32(byteTokens[chunk[i]]),
+			prev:  int32(i - 1),
+			next:  int32(i + 1),
+		})
+	}
+	if n > 0 {
+		nodes[n-1].next = -1
+	}
+	enc.nodes = nodes
+
+	q := &enc.queue
+	*q = (*q)[:0]
+	for i := 0; i+1 < n; i++ {
+		enc.enqueue(int32(i))
+	}
+
+	for len(*q) > 0 {
+		c := q.pop()
+		left := &nodes[c.left]
+		if left.token != c.first || left.next < 0 || nodes[left.next].token != c.next {
+			continue // stale: one of the nodes has been merged since
 		}
-		for b := '¡'; b <= '¬'; b++ {
-			byteEncoding[b] = rune(b)
+		right := &nodes[left.next]
+		left.token = bpeRanks[bpePairKey(c.first, c.next)].Result
+		left.next = right.next
+		if right.next >= 0 {
+			nodes[right.next].prev = c.left
 		}
-		for b := '®'; b <= 'ÿ'; b++ {
-			byteEncoding[b] = rune(b)
+		right.token = -1
+
+		if left.prev >= 0 {
+			enc.enqueue(left.prev)
 		}
-		var next rune = 256
-		for b := 0; b <= 255; b++ {
-			if byteEncoding[b] == 0 {
-				byteEncoding[b] = next
-				next++
-			}
+		if left.next >= 0 {
+			enc.enqueue(c.left)
 		}
-	})
+	}
+
+	enc.result = enc.result[:0]
+	for i := int32(0); i >= 0 && n > 0; i = nodes[i].next {
+		enc.result = append(enc.result, int(nodes[i].token))
+	}
 }
 
-type bpeMerge struct {
-	First  string
-	Second string
-	Result string
+func (enc *bpeEncoder) enqueue(left int32) {
+	first, next := enc.nodes[left].token, enc.nodes[enc.nodes[left].next].token
+	if merge, ok := bpeRanks[bpePairKey(first, next)]; ok {
+		enc.queue.push(bpeCandidate{merge.Rank, left, first, next})
+	}
 }
 
-type bpePair struct {
-	First  string
-	Second string
+// bpeQueue is a binary min-heap of merge candidates ordered by rank, then position.
+type bpeQueue []bpeCandidate
+
+func (a bpeCandidate) less(b bpeCandidate) bool {
+	return a.rank < b.rank || (a.rank == b.rank && a.left < b.left)
 }
 
-// bpe merges consecutive pairs of tokens according to bpeMerges
-// until no further merging is possible.
-//
-// Original code caches the results of this method, but is that really needed?
-func bpe(tokens []string) []string {
-	for {
-		// log.Printf("bpe: % v", tokens)
-		merge := findBestMerge(tokens)
-		if merge == nil {
+func (q *bpeQueue) push(c bpeCandidate) {
+	h := append(*q, c)
+	i := len(h) - 1
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h[i].less(h[parent]) {
 			break
 		}
-		// log.Printf("bpe merge %q + %q", merge.First, merge.Second)
-		tokens = mergeAll(tokens, merge)
+		h[i], h[parent] = h[parent], h[i]
+		i = parent
 	}
-	return tokens
-}
-
-// findBestMerge finds lowest-index bpeMerge where [..., merge.First, merge.Second, ...]
-// occurs somewhere within the tokens.
-func findBestMerge(tokens []string) *bpeMerge {
-	n := len(tokens)
-	var best = math.MaxInt
-	for i := 1; i < n; i++ {
-		i, ok := bpeMergeIndex[bpePair{tokens[i-1], tokens[i]}]
-		if ok && i < best {
-			best = i
+	*q = h
+}
+
+func (q *bpeQueue) pop() bpeCandidate {
+	h := *q
+	result := h[0]
+	n := len(h) - 1
+	h[0] = h[n]
+	h = h[:n]
+	i := 0
+	for {
+		smallest, l, r := i, 2*i+1, 2*i+2
+		if l < n && h[l].less(h[smallest]) {
+			smallest = l
 		}
-	}
-	if best < math.MaxInt {
-		return bpeMerges[best]
-	}
-	return nil
-}
-
-// mergeAll replaces all occurences of [..., merge.First, merge.Second, ...]
-// with [..., merge.Result, ...]
-func mergeAll(tokens []string, merge *bpeMerge) []string {
-	n := len(tokens)
-	dst := 0
-	for src := 0; src < n; src++ {
-		if tokens[src] == merge.First && src+1 < n && tokens[src+1] == merge.Second {
-			tokens[dst] = merge.Result
-			src++
-		} else {
-			tokens[dst] = tokens[src]
+		if r < n && h[r].less(h[smallest]) {
+			smallest = r
+		}
+		if smallest == i {
+			break
 		}
-		dst++
+		h[i], h[smallest] = h[smallest], h[i]
+		i = smallest
 	}
-	return tokens[:dst]
+	*q = h
+	return result
 }
 
 // split enumerates consecutive token candidates (chunks) in a string
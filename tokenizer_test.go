@@ -1,9 +1,11 @@
 package openai
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestTokenizer(t *testing.T) {
@@ -50,3 +52,175 @@ func formatTokens(tokens []int) string {
 	buf.WriteString("]")
 	return buf.String()
 }
+
+func TestChatTokenCount(t *testing.T) {
+	const model = ModelChatGPT4
+	weather := map[string]any{
+		"name":        "get_weather",
+		"description": "Get the current weather",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city": map[string]any{"type": "string", "description": "City name"},
+				"unit": map[string]any{"type": "string", "enum": []string{"c", "f"}},
+			},
+			"required": []string{"city"},
+		},
+	}
+	tests := []struct {
+		name     string
+		msgs     []Msg
+		opt      Options
+		expected int
+	}{
+		{"empty", nil, Options{Model: model}, 3},
+		{"single user msg", []Msg{UserMsg("Hello, world.")}, Options{Model: model}, 3 + 3 + 1 + 4},
+		{"named msg", []Msg{{Role: User, Content: "Hello, world.", Name: "bob"}}, Options{Model: model}, 3 + 3 + 1 + 4 + 1 + 2},
+		{"legacy framing", []Msg{{Role: User, Content: "Hello, world.", Name: "bob"}}, Options{Model: "gpt-3.5-turbo-0301"}, 3 + 4 + 1 + 4 - 1 + 2},
+		{"function call", []Msg{{Role: Assistant, FunctionCall: &FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}}}, Options{Model: model}, 3 + 3 + 1 + 3 + 3 + 6},
+		{"functions", []Msg{UserMsg("Hello, world.")}, Options{Model: model, Functions: []any{weather}}, 11 + 9 + 48},
+		{"functions with system msg", []Msg{SystemMsg("Hi"), UserMsg("Hello, world.")}, Options{Model: model, Functions: []any{weather}}, 11 + 5 + 9 + 48 - 4},
+		{"tools", []Msg{UserMsg("Hello, world.")}, Options{Model: model, Tools: []any{map[string]any{"type": "function", "function": weather}}}, 11 + 9 + 48},
+	}
+	for _, test := range tests {
+		if actual := ChatPromptTokenCount(test.msgs, test.opt); actual != test.expected {
+			t.Errorf("** %s: ChatPromptTokenCount = %d, wanted %d", test.name, actual, test.expected)
+		}
+	}
+}
+
+const benchmarkText = "Many words map to one token, but some don't: indivisible.\n\nUnicode characters like emojis may be split into many tokens containing the underlying bytes: 🤚🏾\n\nSequences of characters commonly found next to each other may be grouped together: 1234567890\n\n"
+
+func BenchmarkTokenCountLargeDocument(b *testing.B) {
+	text := strings.Repeat(benchmarkText, 5000) // ~1 MB
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TokenCount(text, ModelDefaultChat)
+	}
+}
+
+func BenchmarkTokenCountUniqueWords(b *testing.B) {
+	var buf strings.Builder
+	for i := 0; buf.Len() < 1024*1024; i++ {
+		buf.WriteString(" w")
+		buf.WriteString(strconv.FormatInt(int64(i)*7919, 36))
+	}
+	text := buf.String()
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TokenCount(text, ModelDefaultChat)
+	}
+}
+
+func BenchmarkTokenCountLongWord(b *testing.B) {
+	text := strings.Repeat("abcdefghijklmnopqrstuvwxyz", 4000) // a single 100 KB chunk
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TokenCount(text, ModelDefaultChat)
+	}
+}
+
+func TestDecodeE(t *testing.T) {
+	if _, err := DecodeE([]int{15496, 1000000}, ModelDefaultChat); err == nil {
+		t.Errorf("** DecodeE of unknown token succeeded, wanted error")
+	}
+	if s, err := DecodeE([]int{15496, 11, 995, 13}, ModelDefaultChat); err != nil || s != "Hello, world." {
+		t.Errorf("** DecodeE = %q, %v, wanted %q", s, err, "Hello, world.")
+	}
+}
+
+func TestTokenDecoder(t *testing.T) {
+	const input = "Emojis: 🤚🏾, Привет, 你好世界"
+	d := NewTokenDecoder(ModelDefaultChat)
+	var pieces []string
+	for _, token := range Encode(input, ModelDefaultChat) {
+		s, err := d.Add(token)
+		if err != nil {
+			t.Fatalf("** Add(%d) failed: %v", token, err)
+		}
+		if !utf8.ValidString(s) {
+			t.Errorf("** Add(%d) = %q, which is not valid UTF-8", token, s)
+		}
+		pieces = append(pieces, s)
+	}
+	if s := d.Flush(); s != "" {
+		t.Errorf("** Flush = %q, wanted empty", s)
+	}
+	if actual := strings.Join(pieces, ""); actual != input {
+		t.Errorf("** decoded %q, wanted %q", actual, input)
+	}
+
+	d = NewTokenDecoder(ModelDefaultChat)
+	if s, _ := d.Add(8582); s != "" {
+		t.Errorf("** Add(8582) = %q, wanted incomplete character to be held back", s)
+	}
+	if s := d.Flush(); s != "\xf0\x9f" {
+		t.Errorf("** Flush = %q, wanted the held back bytes", s)
+	}
+}
+
+func TestEncodeWithOffsets(t *testing.T) {
+	const model = ModelDefaultChat
+	spans := EncodeWithOffsets("Hello, world.", model)
+	expected := []TokenSpan{{15496, 0, 5}, {11, 5, 6}, {995, 6, 12}, {13, 12, 13}}
+	if len(spans) != len(expected) {
+		t.Fatalf("** EncodeWithOffsets = %v, wanted %v", spans, expected)
+	}
+	for i := range spans {
+		if spans[i] != expected[i] {
+			t.Errorf("** EncodeWithOffsets()[%d] = %v, wanted %v", i, spans[i], expected[i])
+		}
+	}
+
+	input := "Emojis: 🤚🏾\n\n  Привет, мир! 1234567890"
+	spans = EncodeWithOffsets(input, model)
+	tokens := Encode(input, model)
+	if len(spans) != len(tokens) {
+		t.Fatalf("** EncodeWithOffsets returned %d spans, Encode returned %d tokens", len(spans), len(tokens))
+	}
+	offset := 0
+	for i, span := range spans {
+		if span.Token != tokens[i] || span.Start != offset || span.Text(input) != Decode([]int{span.Token}, model) {
+			t.Errorf("** EncodeWithOffsets()[%d] = %v, wanted token %d starting at %d", i, span, tokens[i], offset)
+		}
+		offset = span.End
+	}
+	if offset != len(input) {
+		t.Errorf("** spans end at %d, wanted %d", offset, len(input))
+	}
+}
+
+func TestTokenizerEncoding(t *testing.T) {
+	tests := []struct {
+		base     string
+		variants []string
+		encoding string
+	}{
+		{"gpt-4o", []string{"gpt-4o-2024-08-06", "ft:gpt-4o-2024-08-06:acme::abc123"}, EncodingO200k},
+		{"gpt-4o-mini", []string{"gpt-4o-mini-2024-07-18", "ft:gpt-4o-mini-2024-07-18:acme::abc123"}, EncodingO200k},
+		{"gpt-4", []string{"gpt-4-0613", "ft:gpt-4-0613:acme::abc123"}, EncodingCL100k},
+		{"gpt-3.5-turbo", []string{"gpt-3.5-turbo-0125", "ft:gpt-3.5-turbo-0125:acme::abc123"}, EncodingCL100k},
+		{"davinci", []string{"davinci:ft-acme-2023-03-01-12-00-00"}, EncodingR50k},
+	}
+	for _, test := range tests {
+		for _, model := range append([]string{test.base}, test.variants...) {
+			if a, err := ModelEncoding(model); err != nil || a != test.encoding {
+				t.Errorf("** ModelEncoding(%q) = %q, %v, wanted %q", model, a, err, test.encoding)
+			}
+			a, err := TokenizerEncoding(model)
+			if a != EncodingR50k || errors.Is(err, ErrUnsupportedEncoding) != (test.encoding != EncodingR50k) {
+				t.Errorf("** TokenizerEncoding(%q) = %q, %v", model, a, err)
+			}
+		}
+	}
+
+	if _, err := TokenizerEncoding("gpt-5-2025-01-01"); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("** TokenizerEncoding(gpt-5 snapshot) error = %v, wanted ErrUnknownModel", err)
+	}
+	if _, err := TokenizerEncoding(ModelChatGPT4oMini); err == nil || err.Error() != `unsupported tokenizer encoding o200k_base of "gpt-4o-mini", approximated with r50k_base` {
+		t.Errorf("** TokenizerEncoding(gpt-4o-mini) error = %v", err)
+	}
+}
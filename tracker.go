@@ -0,0 +1,258 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned (wrapped) by calls that would exceed the budget of their CostTracker.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// CostTracker aggregates usage and costs of API calls per model, per tag and per time window,
+// and optionally enforces a hard budget. Attach it to calls via WithCostTracker.
+//
+// The zero value tracks costs without a budget. Safe for concurrent use.
+type CostTracker struct {
+	// Budget is the maximum amount to spend (per Window if set); 0 means no limit.
+	// Calls whose estimated cost would exceed the remaining budget fail with ErrBudgetExceeded.
+	Budget Price
+
+	// Window is the duration of time windows to aggregate costs by, e.g. 24 * time.Hour.
+	// If set, Budget applies to the current window; otherwise, to the lifetime of the tracker.
+	Window time.Duration
+
+	// Now returns the current time; defaults to time.Now.
+	Now func() time.Time
+
+	mut      sync.Mutex
+	total    CostStats
+	byModel  map[string]CostStats
+	byTag    map[string]CostStats
+	byWindow map[time.Time]CostStats
+	reserved Price // estimated cost of calls in flight
+}
+
+// CostStats is the aggregate usage and cost of a number of calls.
+type CostStats struct {
+	Calls int
+	Usage Usage
+	Cost  Price
+}
+
+func (s CostStats) add(usage Usage, cost Price) CostStats {
+	return CostStats{s.Calls + 1, s.Usage.Add(usage), s.Cost + cost}
+}
+
+// Add returns the sum of two usages, e.g. to aggregate usage across calls.
+func (u Usage) Add(v Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + v.PromptTokens,
+		CompletionTokens: u.CompletionTokens + v.CompletionTokens,
+		TotalTokens:      u.TotalTokens + v.TotalTokens,
+		PromptTokensDetails: PromptTokensDetails{
+			CachedTokens: u.PromptTokensDetails.CachedTokens + v.PromptTokensDetails.CachedTokens,
+			AudioTokens:  u.PromptTokensDetails.AudioTokens + v.PromptTokensDetails.AudioTokens,
+		},
+		CompletionTokensDetails: CompletionTokensDetails{
+			ReasoningTokens:          u.CompletionTokensDetails.ReasoningTokens + v.CompletionTokensDetails.ReasoningTokens,
+			AudioTokens:              u.CompletionTokensDetails.AudioTokens + v.CompletionTokensDetails.AudioTokens,
+			AcceptedPredictionTokens: u.CompletionTokensDetails.AcceptedPredictionTokens + v.CompletionTokensDetails.AcceptedPredictionTokens,
+			RejectedPredictionTokens: u.CompletionTokensDetails.RejectedPredictionTokens + v.CompletionTokensDetails.RejectedPredictionTokens,
+		},
+	}
+}
+
+// Record adds the usage of a call made with the given model to the stats, and returns its cost.
+// Usage is recorded even if the cost cannot be computed, in which case an error is returned.
+func (t *CostTracker) Record(model, tag string, usage Usage) (Price, error) {
+	c, err := UsageCost(usage, model)
+	cost := c.Total()
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if t.byModel == nil {
+		t.byModel = make(map[string]CostStats)
+		t.byTag = make(map[string]CostStats)
+		t.byWindow = make(map[time.Time]CostStats)
+	}
+	t.total = t.total.add(usage, cost)
+	t.byModel[model] = t.byModel[model].add(usage, cost)
+	t.byTag[tag] = t.byTag[tag].add(usage, cost)
+	if t.Window > 0 {
+		w := t.windowStart()
+		t.byWindow[w] = t.byWindow[w].add(usage, cost)
+	}
+	return cost, err
+}
+
+// Check returns ErrBudgetExceeded if spending the given amount
+// would exceed the budget.
+func (t *CostTracker) Check(estimate Price) error {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.checkLocked(estimate)
+}
+
+func (t *CostTracker) checkLocked(estimate Price) error {
+	if t.Budget > 0 && t.spentLocked()+t.reserved+estimate > t.Budget {
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+// reserve checks the budget and, if successful, holds the estimated amount
+// until release is called, so that concurrent calls cannot exceed the budget together.
+func (t *CostTracker) reserve(estimate Price) error {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if err := t.checkLocked(estimate); err != nil {
+		return err
+	}
+	t.reserved += estimate
+	return nil
+}
+
+func (t *CostTracker) release(estimate Price) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.reserved -= estimate
+}
+
+// Spent returns the amount spent in the current window if Window is set, or in total otherwise.
+func (t *CostTracker) Spent() Price {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.spentLocked()
+}
+
+func (t *CostTracker) spentLocked() Price {
+	if t.Window > 0 {
+		return t.byWindow[t.windowStart()].Cost
+	}
+	return t.total.Cost
+}
+
+// Total returns the stats across all calls.
+func (t *CostTracker) Total() CostStats {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.total
+}
+
+// ByModel returns the stats per model.
+func (t *CostTracker) ByModel() map[string]CostStats {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return copyStats(t.byModel)
+}
+
+// ByTag returns the stats per tag passed to WithCostTracker.
+func (t *CostTracker) ByTag() map[string]CostStats {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return copyStats(t.byTag)
+}
+
+// ByWindow returns the stats per time window, keyed by window start time. Empty if Window is not set.
+func (t *CostTracker) ByWindow() map[time.Time]CostStats {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return copyStats(t.byWindow)
+}
+
+func (t *CostTracker) windowStart() time.Time {
+	now := time.Now
+	if t.Now != nil {
+		now = t.Now
+	}
+	return now().UTC().Truncate(t.Window)
+}
+
+func copyStats[K comparable](m map[K]CostStats) map[K]CostStats {
+	result := make(map[K]CostStats, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+type costTrackerKey struct{}
+
+type costTrackerValue struct {
+	tracker *CostTracker
+	tag     string
+}
+
+// WithCostTracker returns a context that makes Chat, StreamChat, Complete and ComputeEmbedding
+// calls check their estimated cost against the tracker's budget and record their usage
+// under the given tag (e.g. a customer or feature name; can be empty).
+func WithCostTracker(ctx context.Context, tracker *CostTracker, tag string) context.Context {
+	return context.WithValue(ctx, costTrackerKey{}, costTrackerValue{tracker, tag})
+}
+
+// trackedCall is a call made under a CostTracker, see trackCall.
+type trackedCall struct {
+	tracker  *CostTracker
+	tag      string
+	model    string
+	reserved Price
+}
+
+// trackCall reserves the estimated cost of a call with the CostTracker attached to ctx, if any.
+// estimate returns the number of prompt tokens and the maximum number of completion tokens
+// per choice, with 0 meaning as many as the model allows; it is only called if the tracker
+// has a budget. The result must be finished, and is nil if there's no tracker.
+func trackCall(ctx context.Context, callID, model string, choices int, estimate func() (promptTokens, completionTokens int)) (*trackedCall, error) {
+	v, ok := ctx.Value(costTrackerKey{}).(costTrackerValue)
+	if !ok || v.tracker == nil {
+		return nil, nil
+	}
+	tc := &trackedCall{tracker: v.tracker, tag: v.tag, model: model}
+	if v.tracker.Budget > 0 {
+		promptTokens, completionTokens := estimate()
+		if completionTokens == 0 {
+			if info, err := LookupModel(model); err == nil {
+				completionTokens = info.ContextWindow - promptTokens
+				if info.MaxOutputTokens > 0 && completionTokens > info.MaxOutputTokens {
+					completionTokens = info.MaxOutputTokens
+				}
+			}
+		}
+		if choices > 1 {
+			completionTokens *= choices
+		}
+		cost, err := CostE(promptTokens, completionTokens, model)
+		if err != nil {
+			return nil, &Error{
+				CallID:  callID,
+				Message: "cannot estimate cost to enforce budget",
+				Cause:   err,
+			}
+		}
+		if err := v.tracker.reserve(cost); err != nil {
+			return nil, &Error{
+				CallID:  callID,
+				Message: "estimated cost " + cost.String() + " exceeds remaining budget",
+				Cause:   err,
+			}
+		}
+		tc.reserved = cost
+	}
+	return tc, nil
+}
+
+// finish releases the reservation and records the usage, if known. Tolerates nil.
+func (tc *trackedCall) finish(usage *Usage) {
+	if tc == nil {
+		return
+	}
+	if tc.reserved != 0 {
+		tc.tracker.release(tc.reserved)
+		tc.reserved = 0
+	}
+	if usage != nil {
+		tc.tracker.Record(tc.model, tc.tag, *usage) // unknown prices are not the caller's problem at this point
+	}
+}
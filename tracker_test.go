@@ -0,0 +1,67 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCostTracker(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	tracker := &CostTracker{Window: 24 * time.Hour, Now: func() time.Time { return now }}
+
+	usage := Usage{PromptTokens: 1_000_000, CompletionTokens: 100_000, TotalTokens: 1_100_000}
+	if cost, err := tracker.Record(ModelChatGPT4o, "alice", usage); err != nil || cost.String() != "$6.50" {
+		t.Errorf("** Record = %v, %v, wanted $6.50", cost, err)
+	}
+	now = now.Add(2 * time.Hour)
+	tracker.Record(ModelChatGPT4oMini, "bob", usage)
+	tracker.Record(ModelChatGPT4o, "bob", usage)
+	if _, err := tracker.Record("unknown-model", "bob", usage); err == nil {
+		t.Errorf("** Record of unknown model succeeded, wanted error")
+	}
+
+	if a := tracker.Total(); a.Calls != 4 || a.Usage.TotalTokens != 4_400_000 || a.Cost.String() != "$13.21" {
+		t.Errorf("** Total = %+v", a)
+	}
+	if a := tracker.ByModel()[ModelChatGPT4o]; a.Calls != 2 || a.Cost.String() != "$13.00" {
+		t.Errorf("** ByModel[gpt-4o] = %+v", a)
+	}
+	if a := tracker.ByTag()["bob"]; a.Calls != 3 || a.Cost.String() != "$6.71" {
+		t.Errorf("** ByTag[bob] = %+v", a)
+	}
+	if a := len(tracker.ByWindow()); a != 2 {
+		t.Errorf("** len(ByWindow) = %d, wanted 2", a)
+	}
+	if a := tracker.Spent().String(); a != "$6.71" {
+		t.Errorf("** Spent = %s, wanted current window only", a)
+	}
+}
+
+func TestCostTrackerBudget(t *testing.T) {
+	tracker := &CostTracker{Budget: 2000} // exactly one gpt-4o call with 1 prompt and 1 completion token
+	ctx := WithCostTracker(context.Background(), tracker, "")
+	estimate := func() (int, int) { return 1, 1 }
+
+	tc1, err := trackCall(ctx, "Test", ModelChatGPT4o, 1, estimate)
+	if err != nil {
+		t.Fatalf("** first call refused: %v", err)
+	}
+	if _, err := trackCall(ctx, "Test", ModelChatGPT4o, 1, estimate); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("** concurrent call error = %v, wanted ErrBudgetExceeded", err)
+	}
+	tc1.finish(nil)
+
+	tc2, err := trackCall(ctx, "Test", ModelChatGPT4o, 1, estimate)
+	if err != nil {
+		t.Fatalf("** call after release refused: %v", err)
+	}
+	tc2.finish(&Usage{PromptTokens: 1, CompletionTokens: 1})
+	if _, err := trackCall(ctx, "Test", ModelChatGPT4o, 1, estimate); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("** call after spending budget error = %v, wanted ErrBudgetExceeded", err)
+	}
+	if _, err := trackCall(context.Background(), "Test", ModelChatGPT4o, 1, estimate); err != nil {
+		t.Errorf("** untracked call refused: %v", err)
+	}
+}
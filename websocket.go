@@ -0,0 +1,303 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// This is a minimal RFC 6455 WebSocket implementation, just enough for the Realtime API:
+// no extensions, no subprotocols, messages are read whole.
+
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+
+	wsCloseNormal      = 1000
+	wsCloseProtocol    = 1002
+	wsCloseNoStatus    = 1005
+	wsCloseInvalidData = 1007
+	wsCloseTooBig      = 1009
+
+	wsMaxControlPayload = 125
+	wsGUID              = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+var errWebSocketClosed = errors.New("websocket connection closed")
+
+// WebSocketCloseError is returned when reading from a WebSocket connection
+// closed by the other side.
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket closed with code %d", e.Code)
+	}
+	return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Reason)
+}
+
+type wsConn struct {
+	rwc     io.ReadWriteCloser
+	br      *bufio.Reader
+	client  bool // clients mask their frames, servers don't
+	maxSize int
+
+	writeMut sync.Mutex
+	closed   bool
+}
+
+func newWSConn(rwc io.ReadWriteCloser, br *bufio.Reader, client bool) *wsConn {
+	if br == nil {
+		br = bufio.NewReader(rwc)
+	}
+	return &wsConn{rwc: rwc, br: br, client: client, maxSize: 64 * 1024 * 1024}
+}
+
+// dialWebSocket performs the opening handshake via client, which hands over the
+// connection after a 101 response. ctx only bounds the handshake.
+func dialWebSocket(ctx context.Context, callID, endpoint string, header http.Header, client *http.Client) (*wsConn, error) {
+	var keyRaw [16]byte
+	must(rand.Read(keyRaw[:]))
+	key := base64.StdEncoding.EncodeToString(keyRaw[:])
+
+	r := must(http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil))
+	for k, vv := range header {
+		r.Header[k] = vv
+	}
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", key)
+
+	resp, err := roundTrip(&Call{ID: callID, Request: r}, client)
+	if err != nil {
+		return nil, &Error{
+			CallID:    callID,
+			IsNetwork: true,
+			Cause:     err,
+		}
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		outputRaw, _ := io.ReadAll(resp.Body)
+		return nil, &Error{
+			CallID:            callID,
+			StatusCode:        resp.StatusCode,
+			Message:           "websocket upgrade failed",
+			RawResponseBody:   outputRaw,
+			PrintResponseBody: true,
+		}
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, &Error{
+			CallID:     callID,
+			StatusCode: resp.StatusCode,
+			Message:    "HTTP client does not support protocol upgrades",
+		}
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") || resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) {
+		rwc.Close()
+		return nil, &Error{
+			CallID:     callID,
+			StatusCode: resp.StatusCode,
+			Message:    "invalid websocket handshake response",
+		}
+	}
+	return newWSConn(rwc, nil, true), nil
+}
+
+func wsAcceptKey(key string) string {
+	h := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// ReadMessage returns the next text or binary message, answering pings and close
+// frames along the way. Must not be called concurrently.
+func (c *wsConn) ReadMessage() (opcode byte, data []byte, err error) {
+	var msgOpcode byte
+	var msg []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			e := &WebSocketCloseError{Code: wsCloseNoStatus}
+			if len(payload) >= 2 {
+				e.Code = int(binary.BigEndian.Uint16(payload))
+				e.Reason = string(payload[2:])
+			}
+			c.Close(wsCloseNormal, "")
+			return 0, nil, e
+		case wsText, wsBinary:
+			if msgOpcode != 0 {
+				return 0, nil, c.fail(wsCloseProtocol, "expected continuation frame")
+			}
+			msgOpcode = op
+		case wsContinuation:
+			if msgOpcode == 0 {
+				return 0, nil, c.fail(wsCloseProtocol, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(wsCloseProtocol, fmt.Sprintf("unknown opcode %d", op))
+		}
+		if len(msg)+len(payload) > c.maxSize {
+			return 0, nil, c.fail(wsCloseTooBig, "message too big")
+		}
+		msg = append(msg, payload...)
+		if fin {
+			if msgOpcode == wsText && !utf8.Valid(msg) {
+				return 0, nil, c.fail(wsCloseInvalidData, "invalid UTF-8")
+			}
+			return msgOpcode, msg, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.br, hdr[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = hdr[0]&0x80 != 0
+	opcode = hdr[0] & 0x0F
+	if hdr[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(wsCloseProtocol, "reserved bits set")
+	}
+	masked := hdr[1]&0x80 != 0
+	n := uint64(hdr[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= wsClose && (n > wsMaxControlPayload || !fin) {
+		return false, 0, nil, c.fail(wsCloseProtocol, "invalid control frame")
+	}
+	if n > uint64(c.maxSize) {
+		return false, 0, nil, c.fail(wsCloseTooBig, "message too big")
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends a single-frame message. Safe for concurrent use.
+func (c *wsConn) WriteMessage(opcode byte, data []byte) error {
+	return c.writeFrame(opcode, data)
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMut.Lock()
+	defer c.writeMut.Unlock()
+	if c.closed {
+		return errWebSocketClosed
+	}
+
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|opcode)
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xFFFF:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	if c.client {
+		var mask [4]byte
+		must(rand.Read(mask[:]))
+		buf = append(buf, mask[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		for i := range buf[start:] {
+			buf[start+i] ^= mask[i%4]
+		}
+	} else {
+		buf = append(buf, payload...)
+	}
+	_, err := c.rwc.Write(buf)
+	return err
+}
+
+// Close sends a close frame (best effort) and closes the connection.
+func (c *wsConn) Close(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > wsMaxControlPayload {
+		payload = payload[:wsMaxControlPayload]
+	}
+	werr := c.writeFrame(wsClose, payload)
+
+	c.writeMut.Lock()
+	defer c.writeMut.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	err := c.rwc.Close()
+	if err == nil && werr != nil && werr != errWebSocketClosed {
+		err = werr
+	}
+	return err
+}
+
+func (c *wsConn) fail(code int, reason string) error {
+	c.Close(code, reason)
+	return &WebSocketCloseError{Code: code, Reason: reason}
+}